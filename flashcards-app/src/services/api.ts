@@ -2,7 +2,10 @@ import axios from 'axios';
 import type { Note } from '../types';
 import type { Message } from '../types';
 
-const API_BASE_URL = import.meta.env.VITE_API_BASE_URL!;
+// Same-origin by default: the Go server serves this app and the API under
+// /api, and `npm run dev` proxies /api to it. Set VITE_API_BASE_URL (including
+// the /api/v1 suffix) only when the API lives on another origin.
+const API_BASE_URL = import.meta.env.VITE_API_BASE_URL ?? '/api/v1';
 
 const apiClient = axios.create({
   baseURL: API_BASE_URL,
@@ -4,4 +4,11 @@ import react from '@vitejs/plugin-react'
 // https://vite.dev/config/
 export default defineConfig({
   plugins: [react()],
+  server: {
+    proxy: {
+      // Forward API calls to the Go server during development so the app
+      // talks to the same origin it is served from in production.
+      '/api': process.env.API_PROXY_TARGET ?? 'http://localhost:8080',
+    },
+  },
 })
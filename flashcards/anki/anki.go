@@ -0,0 +1,181 @@
+// Package anki reads and writes Anki deck packages (.apkg).
+//
+// A package is a zip archive holding an SQLite collection and its media.
+// This package understands the collection schema used by collection.anki2
+// and collection.anki21, which every Anki version can import and which
+// current versions still write when "Support older Anki versions" is ticked
+// on export. Packages that only contain the newer collection.anki21b are
+// rejected with an error that says so. Media files are neither read nor
+// written.
+package anki
+
+import (
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// NoteKind is the kind of Anki note type a note uses.
+type NoteKind int
+
+const (
+	// KindStandard note types make one card per template.
+	KindStandard NoteKind = 0
+	// KindCloze note types make one card per cloze deletion, {{c1::...}}.
+	KindCloze NoteKind = 1
+)
+
+// CardType is where a card is in Anki's learning cycle.
+type CardType int
+
+const (
+	CardNew        CardType = 0
+	CardLearning   CardType = 1
+	CardReview     CardType = 2
+	CardRelearning CardType = 3
+)
+
+// Note is one Anki note with the scheduling state of its cards.
+type Note struct {
+	// GUID identifies the note across collections. Anki uses it to update a
+	// note it already has instead of adding a duplicate.
+	GUID string
+	// NoteType is the name of the note type, e.g. "Basic" or "Cloze".
+	NoteType string
+	Kind     NoteKind
+	// Deck is the full deck name, with "::" between parent and child decks.
+	Deck     string
+	Fields   []Field
+	Tags     []string
+	Modified time.Time
+	Cards    []Card
+}
+
+// Field is one named field of a note. Values are HTML.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Card is the scheduling state of one card of a note.
+type Card struct {
+	// Ord is the template the card is made from, or the cloze number minus
+	// one for cloze notes.
+	Ord      int      `json:"ord"`
+	Template string   `json:"template,omitempty"`
+	Type     CardType `json:"type"`
+	// Queue is Anki's queue for the card; negative values mean suspended
+	// (-1) or buried (-2, -3).
+	Queue int `json:"queue"`
+	// Due is the position of a new card in the new queue, or the Unix time
+	// a learning card is next due. Review cards use DueDate instead.
+	Due     int64     `json:"due,omitempty"`
+	DueDate time.Time `json:"due_date,omitzero"`
+	// Interval is the days between reviews, and EaseFactor the interval
+	// multiplier in permille (2500 is 250%).
+	Interval   int `json:"interval"`
+	EaseFactor int `json:"ease_factor"`
+	Reps       int `json:"reps"`
+	Lapses     int `json:"lapses"`
+}
+
+// schemaVersion is the collection schema read and written by this package.
+const schemaVersion = 11
+
+// schema creates an empty collection with the schema 11 tables and indexes.
+const schema = `
+CREATE TABLE col (
+    id integer PRIMARY KEY,
+    crt integer NOT NULL,
+    mod integer NOT NULL,
+    scm integer NOT NULL,
+    ver integer NOT NULL,
+    dty integer NOT NULL,
+    usn integer NOT NULL,
+    ls integer NOT NULL,
+    conf text NOT NULL,
+    models text NOT NULL,
+    decks text NOT NULL,
+    dconf text NOT NULL,
+    tags text NOT NULL
+);
+CREATE TABLE notes (
+    id integer PRIMARY KEY,
+    guid text NOT NULL,
+    mid integer NOT NULL,
+    mod integer NOT NULL,
+    usn integer NOT NULL,
+    tags text NOT NULL,
+    flds text NOT NULL,
+    sfld integer NOT NULL,
+    csum integer NOT NULL,
+    flags integer NOT NULL,
+    data text NOT NULL
+);
+CREATE TABLE cards (
+    id integer PRIMARY KEY,
+    nid integer NOT NULL,
+    did integer NOT NULL,
+    ord integer NOT NULL,
+    mod integer NOT NULL,
+    usn integer NOT NULL,
+    type integer NOT NULL,
+    queue integer NOT NULL,
+    due integer NOT NULL,
+    ivl integer NOT NULL,
+    factor integer NOT NULL,
+    reps integer NOT NULL,
+    lapses integer NOT NULL,
+    left integer NOT NULL,
+    odue integer NOT NULL,
+    odid integer NOT NULL,
+    flags integer NOT NULL,
+    data text NOT NULL
+);
+CREATE TABLE revlog (
+    id integer PRIMARY KEY,
+    cid integer NOT NULL,
+    usn integer NOT NULL,
+    ease integer NOT NULL,
+    ivl integer NOT NULL,
+    lastIvl integer NOT NULL,
+    factor integer NOT NULL,
+    time integer NOT NULL,
+    type integer NOT NULL
+);
+CREATE TABLE graves (
+    usn integer NOT NULL,
+    oid integer NOT NULL,
+    type integer NOT NULL
+);
+CREATE INDEX ix_notes_usn ON notes (usn);
+CREATE INDEX ix_cards_usn ON cards (usn);
+CREATE INDEX ix_revlog_usn ON revlog (usn);
+CREATE INDEX ix_cards_nid ON cards (nid);
+CREATE INDEX ix_cards_sched ON cards (did, queue, due);
+CREATE INDEX ix_revlog_cid ON revlog (cid);
+CREATE INDEX ix_notes_csum ON notes (csum);
+`
+
+// fieldSeparator joins the fields of a note in the notes.flds column.
+const fieldSeparator = "\x1f"
+
+// model is the subset of an Anki note type definition this package uses.
+type model struct {
+	ID   int64    `json:"id"`
+	Name string   `json:"name"`
+	Type NoteKind `json:"type"`
+	Flds []struct {
+		Name string `json:"name"`
+		Ord  int    `json:"ord"`
+	} `json:"flds"`
+	Tmpls []struct {
+		Name string `json:"name"`
+		Ord  int    `json:"ord"`
+	} `json:"tmpls"`
+}
+
+type deck struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
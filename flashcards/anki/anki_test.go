@@ -0,0 +1,66 @@
+package anki
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// TestPackageRoundTrip writes a package and reads it back, which exercises
+// the SQLite driver on both sides.
+func TestPackageRoundTrip(t *testing.T) {
+	modified := time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC)
+	notes := []Note{
+		{
+			GUID:     "f7Q+ab1~Zx",
+			NoteType: "Basic",
+			Kind:     KindStandard,
+			Deck:     "History::Vikings",
+			Fields:   []Field{{Name: "Front", Value: "Who wrote the Prose Edda?"}, {Name: "Back", Value: "Snorri Sturluson"}},
+			Tags:     []string{"history", "norse"},
+			Modified: modified,
+			Cards:    []Card{{Ord: 0, Type: CardReview, Queue: 2, DueDate: time.Date(2026, time.October, 20, 0, 0, 0, 0, time.UTC), Interval: 12, EaseFactor: 2500, Reps: 4, Lapses: 1}},
+		},
+		{
+			GUID:     "k2Lm9Pq0Rs",
+			NoteType: "Cloze",
+			Kind:     KindCloze,
+			Deck:     "Languages::Icelandic",
+			Fields:   []Field{{Name: "Text", Value: "{{c1::Þórr}} is the god of thunder"}, {Name: "Back Extra", Value: ""}},
+			Modified: modified,
+			Cards:    []Card{{Ord: 0, Type: CardNew, Queue: 0, Due: 3}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WritePackage(&buf, notes); err != nil {
+		t.Fatalf("WritePackage() error = %v", err)
+	}
+	read, err := ReadPackage(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ReadPackage() error = %v", err)
+	}
+	if len(read) != len(notes) {
+		t.Fatalf("ReadPackage() returned %d notes, want %d", len(read), len(notes))
+	}
+
+	byGUID := make(map[string]Note, len(read))
+	for _, note := range read {
+		byGUID[note.GUID] = note
+	}
+	for _, want := range notes {
+		got, ok := byGUID[want.GUID]
+		if !ok {
+			t.Fatalf("note %s was not read back", want.GUID)
+		}
+		if got.Kind != want.Kind || got.Deck != want.Deck || got.NoteType != want.NoteType {
+			t.Errorf("note %s = %+v, want %+v", want.GUID, got, want)
+		}
+		if len(got.Fields) != len(want.Fields) || got.Fields[0] != want.Fields[0] {
+			t.Errorf("note %s fields = %+v, want %+v", want.GUID, got.Fields, want.Fields)
+		}
+		if len(got.Cards) != 1 || got.Cards[0].Interval != want.Cards[0].Interval || got.Cards[0].Reps != want.Cards[0].Reps {
+			t.Errorf("note %s cards = %+v, want %+v", want.GUID, got.Cards, want.Cards)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package anki
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	lineBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>|</(?:div|p|li|h[1-6]|tr)>`)
+	tagPattern       = regexp.MustCompile(`<[^>]*>`)
+	blankLinePattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToText converts a field value to plain text. Line breaks and block
+// elements become newlines and every other tag, including images and media,
+// is dropped.
+func HTMLToText(value string) string {
+	text := lineBreakPattern.ReplaceAllString(value, "\n")
+	text = tagPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	text = strings.ReplaceAll(text, " ", " ")
+	text = blankLinePattern.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// TextToHTML converts plain text to a field value, escaping it and turning
+// newlines into line breaks.
+func TextToHTML(text string) string {
+	return strings.ReplaceAll(html.EscapeString(text), "\n", "<br>")
+}
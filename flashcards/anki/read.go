@@ -0,0 +1,232 @@
+package anki
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxCollectionSize caps the uncompressed size of the collection inside a
+// package.
+const MaxCollectionSize = 256 << 20
+
+// ErrNewerFormat is returned for packages that only contain a collection in
+// the format introduced in Anki 2.1.50.
+var ErrNewerFormat = errors.New(`package uses the newer Anki format; export it again with "Support older Anki versions" ticked`)
+
+// ReadPackage returns the notes of an .apkg file, with the scheduling state
+// of their cards.
+func ReadPackage(data []byte) ([]Note, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open package: %w", err)
+	}
+
+	entries := make(map[string]*zip.File)
+	for _, entry := range archive.File {
+		entries[entry.Name] = entry
+	}
+	// Packages that support older versions hold collection.anki21 and a
+	// collection.anki2 with the same notes; anki21b-only packages hold a
+	// collection.anki2 whose one note asks the user to upgrade.
+	entry := entries["collection.anki21"]
+	if entry == nil && entries["collection.anki21b"] != nil {
+		return nil, ErrNewerFormat
+	}
+	if entry == nil {
+		entry = entries["collection.anki2"]
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("package has no collection; is it an .apkg file?")
+	}
+
+	path, err := extractCollection(entry)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(path)
+
+	return readCollection(path)
+}
+
+// extractCollection copies the collection to a temporary file, since SQLite
+// can only open databases on disk.
+func extractCollection(entry *zip.File) (string, error) {
+	rc, err := entry.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open collection: %w", err)
+	}
+	defer rc.Close()
+
+	file, err := os.CreateTemp("", "anki-*.sqlite")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary collection: %w", err)
+	}
+	n, err := io.Copy(file, io.LimitReader(rc, MaxCollectionSize+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n > MaxCollectionSize {
+		err = fmt.Errorf("collection exceeds %d bytes", MaxCollectionSize)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to extract collection: %w", err)
+	}
+	return file.Name(), nil
+}
+
+func readCollection(path string) ([]Note, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro&immutable=1")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open collection: %w", err)
+	}
+	defer db.Close()
+
+	var (
+		created               int64
+		ver                   int
+		modelsJSON, decksJSON string
+	)
+	err = db.QueryRow("SELECT crt, ver, models, decks FROM col").Scan(&created, &ver, &modelsJSON, &decksJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collection: %w", err)
+	}
+	if ver > schemaVersion {
+		return nil, ErrNewerFormat
+	}
+
+	var models map[string]model
+	if err := json.Unmarshal([]byte(modelsJSON), &models); err != nil {
+		return nil, fmt.Errorf("failed to decode note types: %w", err)
+	}
+	var decks map[string]deck
+	if err := json.Unmarshal([]byte(decksJSON), &decks); err != nil {
+		return nil, fmt.Errorf("failed to decode decks: %w", err)
+	}
+
+	notes, byID, err := readNotes(db, models)
+	if err != nil {
+		return nil, err
+	}
+	if err := readCards(db, byID, models, decks, time.Unix(created, 0).UTC()); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func readNotes(db *sql.DB, models map[string]model) ([]Note, map[int64]*Note, error) {
+	rows, err := db.Query("SELECT id, guid, mid, mod, tags, flds FROM notes ORDER BY id")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read notes: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		notes []Note
+		ids   []int64
+	)
+	for rows.Next() {
+		var (
+			id, mid, mod     int64
+			guid, tags, flds string
+		)
+		if err := rows.Scan(&id, &guid, &mid, &mod, &tags, &flds); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+
+		m := models[strconv.FormatInt(mid, 10)]
+		note := Note{
+			GUID:     guid,
+			NoteType: m.Name,
+			Kind:     m.Type,
+			Tags:     strings.Fields(tags),
+			Modified: time.Unix(mod, 0).UTC(),
+		}
+		fieldNames := make(map[int]string, len(m.Flds))
+		for _, f := range m.Flds {
+			fieldNames[f.Ord] = f.Name
+		}
+		for i, value := range strings.Split(flds, fieldSeparator) {
+			name := fieldNames[i]
+			if name == "" {
+				name = fmt.Sprintf("Field %d", i+1)
+			}
+			note.Fields = append(note.Fields, Field{Name: name, Value: value})
+		}
+		notes = append(notes, note)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read notes: %w", err)
+	}
+
+	byID := make(map[int64]*Note, len(notes))
+	for i, id := range ids {
+		byID[id] = &notes[i]
+	}
+	return notes, byID, nil
+}
+
+func readCards(db *sql.DB, notes map[int64]*Note, models map[string]model, decks map[string]deck, created time.Time) error {
+	rows, err := db.Query(`
+	SELECT n.mid, c.nid, c.did, c.odid, c.ord, c.type, c.queue, c.due, c.odue, c.ivl, c.factor, c.reps, c.lapses
+	FROM cards c JOIN notes n ON n.id = c.nid
+	ORDER BY c.nid, c.ord`)
+	if err != nil {
+		return fmt.Errorf("failed to read cards: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			mid, nid, did, odid, due, odue int64
+			card                           Card
+		)
+		err := rows.Scan(&mid, &nid, &did, &odid, &card.Ord, &card.Type, &card.Queue, &due, &odue, &card.Interval, &card.EaseFactor, &card.Reps, &card.Lapses)
+		if err != nil {
+			return fmt.Errorf("failed to scan card: %w", err)
+		}
+		note := notes[nid]
+		if note == nil {
+			continue
+		}
+
+		// Cards in a filtered deck remember their home deck and due.
+		if odid != 0 {
+			did, due = odid, odue
+		}
+		if note.Deck == "" {
+			note.Deck = decks[strconv.FormatInt(did, 10)].Name
+		}
+
+		card.Due = due
+		if card.Type == CardReview {
+			// Review cards are due a number of days after the collection
+			// was created; store the date so it survives other collections.
+			card.Due = 0
+			date := created.AddDate(0, 0, int(due))
+			card.DueDate = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		m := models[strconv.FormatInt(mid, 10)]
+		for _, t := range m.Tmpls {
+			if t.Ord == card.Ord || m.Type == KindCloze {
+				card.Template = t.Name
+				break
+			}
+		}
+		note.Cards = append(note.Cards, card)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read cards: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,363 @@
+package anki
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultDeckID is the deck every collection has, named "Default".
+const defaultDeckID = 1
+
+var clozePattern = regexp.MustCompile(`\{\{c(\d+)::`)
+
+// WritePackage writes notes to w as an .apkg file that Anki can import.
+//
+// Each distinct combination of note kind and field names becomes a note
+// type. Standard note types get one card, showing the first field on the
+// front and the others on the back; cloze note types get one card per cloze
+// number in the first field. Cards take their scheduling state from the
+// note's Cards with the same Ord, and are new otherwise.
+func WritePackage(w io.Writer, notes []Note) error {
+	file, err := os.CreateTemp("", "anki-*.sqlite")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary collection: %w", err)
+	}
+	path := file.Name()
+	file.Close()
+	defer os.Remove(path)
+
+	if err := writeCollection(path, notes, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	collection, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read collection: %w", err)
+	}
+
+	archive := zip.NewWriter(w)
+	for _, entry := range []struct {
+		name string
+		data []byte
+	}{
+		{"collection.anki2", collection},
+		// The media file maps numbered files in the package to their names.
+		{"media", []byte("{}")},
+	} {
+		part, err := archive.Create(entry.name)
+		if err != nil {
+			return fmt.Errorf("failed to write package: %w", err)
+		}
+		if _, err := part.Write(entry.data); err != nil {
+			return fmt.Errorf("failed to write package: %w", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write package: %w", err)
+	}
+	return nil
+}
+
+func writeCollection(path string, notes []Note, now time.Time) (err error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("failed to open collection: %w", err)
+	}
+	defer func() {
+		if closeErr := db.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close collection: %w", closeErr)
+		}
+	}()
+
+	if _, err := db.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start collection transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	created := now.Truncate(24 * time.Hour)
+	c := newCollectionWriter(tx, now, created)
+	for i, note := range notes {
+		if err := c.addNote(note, i); err != nil {
+			return err
+		}
+	}
+	if err := c.writeCol(); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit collection: %w", err)
+	}
+	return nil
+}
+
+// collectionWriter adds notes and cards to a new collection, collecting the
+// note types and decks they use for the col row written last.
+type collectionWriter struct {
+	tx      *sql.Tx
+	now     time.Time
+	created time.Time
+	nextID  int64
+	models  map[int64]map[string]any
+	decks   map[int64]map[string]any
+}
+
+func newCollectionWriter(tx *sql.Tx, now, created time.Time) *collectionWriter {
+	c := &collectionWriter{
+		tx:      tx,
+		now:     now,
+		created: created,
+		// Anki uses millisecond timestamps as IDs.
+		nextID: now.UnixMilli(),
+		models: make(map[int64]map[string]any),
+		decks:  make(map[int64]map[string]any),
+	}
+	c.deckID("Default")
+	return c
+}
+
+func (c *collectionWriter) id() int64 {
+	c.nextID++
+	return c.nextID
+}
+
+func (c *collectionWriter) addNote(note Note, position int) error {
+	if len(note.Fields) == 0 {
+		return fmt.Errorf("note %s has no fields", note.GUID)
+	}
+
+	mid := c.modelID(note)
+	did := c.deckID(note.Deck)
+	nid := c.id()
+
+	values := make([]string, len(note.Fields))
+	for i, field := range note.Fields {
+		values[i] = field.Value
+	}
+	sortField := HTMLToText(values[0])
+	modified := note.Modified
+	if modified.IsZero() {
+		modified = c.now
+	}
+
+	_, err := c.tx.Exec(
+		"INSERT INTO notes (id, guid, mid, mod, usn, tags, flds, sfld, csum, flags, data) VALUES (?, ?, ?, ?, -1, ?, ?, ?, ?, 0, '')",
+		nid, note.GUID, mid, modified.Unix(), tagString(note.Tags), strings.Join(values, fieldSeparator), sortField, checksum(sortField),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write note %s: %w", note.GUID, err)
+	}
+
+	for _, ord := range cardOrds(note) {
+		card := Card{Ord: ord, Type: CardNew, Due: int64(position + 1)}
+		for _, scheduled := range note.Cards {
+			if scheduled.Ord == ord {
+				card = scheduled
+			}
+		}
+
+		due := card.Due
+		if card.Type == CardReview {
+			due = int64(card.DueDate.Sub(c.created).Hours() / 24)
+		}
+		_, err := c.tx.Exec(
+			"INSERT INTO cards (id, nid, did, ord, mod, usn, type, queue, due, ivl, factor, reps, lapses, left, odue, odid, flags, data) VALUES (?, ?, ?, ?, ?, -1, ?, ?, ?, ?, ?, ?, ?, 0, 0, 0, 0, '')",
+			c.id(), nid, did, ord, modified.Unix(), card.Type, card.Queue, due, card.Interval, card.EaseFactor, card.Reps, card.Lapses,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write card for note %s: %w", note.GUID, err)
+		}
+	}
+	return nil
+}
+
+// cardOrds returns the cards a note has: the one template of a standard
+// note type, or each cloze number of a cloze note.
+func cardOrds(note Note) []int {
+	if note.Kind != KindCloze {
+		return []int{0}
+	}
+	var ords []int
+	seen := make(map[int]bool)
+	for _, m := range clozePattern.FindAllStringSubmatch(note.Fields[0].Value, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n < 1 || seen[n] {
+			continue
+		}
+		seen[n] = true
+		ords = append(ords, n-1)
+	}
+	if len(ords) == 0 {
+		return []int{0}
+	}
+	return ords
+}
+
+// modelID returns the note type for note's kind and fields, defining it on
+// first use. IDs are derived from the definition, so exporting again keeps
+// them and Anki reuses the note type it already has.
+func (c *collectionWriter) modelID(note Note) int64 {
+	names := make([]string, len(note.Fields))
+	for i, field := range note.Fields {
+		names[i] = field.Name
+	}
+	name := note.NoteType
+	if name == "" {
+		name = "Basic"
+		if note.Kind == KindCloze {
+			name = "Cloze"
+		}
+	}
+
+	id := stableID(fmt.Sprintf("%d\x00%s\x00%s", note.Kind, name, strings.Join(names, "\x00")))
+	if _, ok := c.models[id]; ok {
+		return id
+	}
+
+	fields := make([]map[string]any, len(names))
+	for i, field := range names {
+		fields[i] = map[string]any{"name": field, "ord": i, "sticky": false, "rtl": false, "font": "Arial", "size": 20, "media": []any{}}
+	}
+
+	template, qfmt, afmt := "Card 1", "", ""
+	if note.Kind == KindCloze {
+		template = "Cloze"
+		qfmt = "{{cloze:" + names[0] + "}}"
+		afmt = qfmt
+	} else {
+		qfmt = "{{" + names[0] + "}}"
+		afmt = "{{FrontSide}}\n\n<hr id=answer>\n\n"
+	}
+	var back []string
+	for _, field := range names[1:] {
+		back = append(back, "{{"+field+"}}")
+	}
+	if note.Kind == KindCloze && len(back) > 0 {
+		afmt += "<br>\n"
+	}
+	afmt += strings.Join(back, "<br>\n")
+
+	c.models[id] = map[string]any{
+		"id":        id,
+		"name":      name,
+		"type":      note.Kind,
+		"mod":       c.now.Unix(),
+		"usn":       -1,
+		"sortf":     0,
+		"did":       defaultDeckID,
+		"flds":      fields,
+		"tmpls":     []map[string]any{{"name": template, "ord": 0, "qfmt": qfmt, "afmt": afmt, "bqfmt": "", "bafmt": "", "did": nil}},
+		"css":       ".card {\n font-family: arial;\n font-size: 20px;\n text-align: center;\n color: black;\n background-color: white;\n}\n",
+		"latexPre":  "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage[utf8]{inputenc}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\setlength{\\parindent}{0in}\n\\begin{document}\n",
+		"latexPost": "\\end{document}",
+		"req":       []any{[]any{0, "any", []int{0}}},
+		"tags":      []any{},
+		"vers":      []any{},
+	}
+	return id
+}
+
+// deckID returns the deck with the given name, defining it on first use.
+func (c *collectionWriter) deckID(name string) int64 {
+	if name == "" {
+		name = "Default"
+	}
+	id := int64(defaultDeckID)
+	if name != "Default" {
+		id = stableID(name)
+	}
+	if _, ok := c.decks[id]; !ok {
+		c.decks[id] = map[string]any{
+			"id": id, "name": name, "mod": c.now.Unix(), "usn": -1, "desc": "", "dyn": 0, "conf": 1, "collapsed": false,
+			"extendNew": 10, "extendRev": 50,
+			"newToday": []int{0, 0}, "revToday": []int{0, 0}, "lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+		}
+	}
+	return id
+}
+
+func (c *collectionWriter) writeCol() error {
+	conf := map[string]any{
+		"activeDecks": []int{defaultDeckID}, "curDeck": defaultDeckID, "newSpread": 0, "collapseTime": 1200,
+		"timeLim": 0, "estTimes": true, "dueCounts": true, "curModel": nil, "nextPos": 1,
+		"sortType": "noteFld", "sortBackwards": false, "addToCur": true,
+	}
+	dconf := map[string]any{
+		"1": map[string]any{
+			"id": 1, "name": "Default", "mod": 0, "usn": 0, "maxTaken": 60, "autoplay": true, "timer": 0, "replayq": true, "dyn": false,
+			"new":   map[string]any{"bury": true, "delays": []int{1, 10}, "initialFactor": 2500, "ints": []int{1, 4, 7}, "order": 1, "perDay": 20, "separate": true},
+			"lapse": map[string]any{"delays": []int{10}, "leechAction": 0, "leechFails": 8, "minInt": 1, "mult": 0},
+			"rev":   map[string]any{"bury": true, "ease4": 1.3, "fuzz": 0.05, "ivlFct": 1, "maxIvl": 36500, "minSpace": 1, "perDay": 200},
+		},
+	}
+
+	models := make(map[string]any, len(c.models))
+	for id, m := range c.models {
+		models[strconv.FormatInt(id, 10)] = m
+	}
+	decks := make(map[string]any, len(c.decks))
+	for id, d := range c.decks {
+		decks[strconv.FormatInt(id, 10)] = d
+	}
+
+	encoded := make([]string, 0, 4)
+	for _, v := range []any{conf, models, decks, dconf} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode collection settings: %w", err)
+		}
+		encoded = append(encoded, string(data))
+	}
+
+	_, err := c.tx.Exec(
+		"INSERT INTO col (id, crt, mod, scm, ver, dty, usn, ls, conf, models, decks, dconf, tags) VALUES (1, ?, ?, ?, ?, 0, 0, 0, ?, ?, ?, ?, '{}')",
+		c.created.Unix(), c.now.UnixMilli(), c.now.UnixMilli(), schemaVersion, encoded[0], encoded[1], encoded[2], encoded[3],
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write collection settings: %w", err)
+	}
+	return nil
+}
+
+// tagString formats tags the way the notes.tags column stores them: space
+// separated with a space at each end. Anki tags cannot contain spaces.
+func tagString(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	cleaned := make([]string, len(tags))
+	for i, tag := range tags {
+		cleaned[i] = strings.ReplaceAll(tag, " ", "_")
+	}
+	return " " + strings.Join(cleaned, " ") + " "
+}
+
+// checksum is the notes.csum Anki uses to find duplicates: the first 32 bits
+// of the SHA-1 of the sort field.
+func checksum(sortField string) int64 {
+	sum := sha1.Sum([]byte(sortField))
+	return int64(binary.BigEndian.Uint32(sum[:4]))
+}
+
+// stableID derives a positive ID from s that fits in a JavaScript number, as
+// Anki's IDs must.
+func stableID(s string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return int64(h.Sum64()>>12) + 1
+}
@@ -0,0 +1,65 @@
+// Package apiversion keeps old, unversioned API paths working while clients
+// move to the versioned ones, and tells them that they should.
+package apiversion
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Alias forwards requests under the From path prefix to the same path under To.
+type Alias struct {
+	From string
+	To   string
+}
+
+// Deprecation describes a set of legacy aliases and their retirement dates.
+type Deprecation struct {
+	// Since is when the aliases were deprecated, sent in the Deprecation header.
+	Since time.Time
+	// Sunset is when the aliases will be removed, sent in the Sunset header.
+	Sunset  time.Time
+	Aliases []Alias
+}
+
+// Handler rewrites requests for an aliased path to its replacement before
+// passing them to next, so the alias is served by exactly the same route.
+// Responses carry Deprecation (RFC 9745), Sunset (RFC 8594) and a Link to the
+// successor path. Requests that match no alias pass through untouched.
+func (d Deprecation) Handler(next http.Handler) http.Handler {
+	deprecation := fmt.Sprintf("@%d", d.Since.Unix())
+	sunset := d.Sunset.UTC().Format(http.TimeFormat)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		successor, ok := d.rewrite(r.URL.Path)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Set("Deprecation", deprecation)
+		header.Set("Sunset", sunset)
+		header.Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = successor
+		r2.URL.RawPath = ""
+		next.ServeHTTP(w, r2)
+	})
+}
+
+// rewrite returns the successor of path if it falls under one of the aliases.
+// Prefixes only match whole path segments, so /notes does not capture /notesx.
+func (d Deprecation) rewrite(path string) (string, bool) {
+	for _, alias := range d.Aliases {
+		rest, ok := strings.CutPrefix(path, alias.From)
+		if !ok || (rest != "" && !strings.HasPrefix(rest, "/")) {
+			continue
+		}
+		return alias.To + rest, true
+	}
+	return "", false
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout    = 2 * time.Minute
+	defaultMaxRetries = 3
+	defaultBackoff    = 200 * time.Millisecond
+)
+
+// Client is a typed client for the flashcards API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient replaces the underlying HTTP client, e.g. to add transport middleware.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries sets how many times idempotent requests are retried after a
+// network error or a 429/502/503/504 response, and the initial backoff,
+// which doubles on each attempt. Use 0 retries to disable retrying.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// New creates a Client for the API served at baseURL, e.g. "http://localhost:8080".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme and host are required", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// do sends a request with an optional JSON body and decodes a JSON response
+// into out when out is non-nil.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+	return c.send(ctx, method, path, "application/json", payload, out)
+}
+
+// send is do for an already encoded body of the given content type.
+func (c *Client) send(ctx context.Context, method, path, contentType string, payload []byte, out any) error {
+	attempts := 1
+	if isIdempotent(method) {
+		attempts += c.maxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := c.wait(ctx, attempt); err != nil {
+				return err
+			}
+		}
+
+		retry, err := c.attempt(ctx, method, path, contentType, payload, out)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return lastErr
+}
+
+// attempt performs a single round trip and reports whether a failure is worth retrying.
+func (c *Client) attempt(ctx context.Context, method, path, contentType string, payload []byte, out any) (bool, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL.String()+path, reader)
+	if err != nil {
+		return false, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		return true, fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return isRetryableStatus(res.StatusCode), newAPIError(method, path, res)
+	}
+
+	if out == nil || res.StatusCode == http.StatusNoContent {
+		return false, nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+	}
+	return false, nil
+}
+
+func (c *Client) wait(ctx context.Context, attempt int) error {
+	delay := c.backoff << (attempt - 1)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
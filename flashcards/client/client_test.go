@@ -0,0 +1,265 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-ai-eng-flashcards/client"
+	"go-ai-eng-flashcards/config"
+	"go-ai-eng-flashcards/db"
+	"go-ai-eng-flashcards/models"
+	"go-ai-eng-flashcards/server"
+)
+
+// newAPI returns the server's handler on in-memory storage, as cmd/main.go
+// builds it.
+func newAPI(t *testing.T) http.Handler {
+	t.Helper()
+	cfg := config.Default()
+	cfg.GeminiAPIKey = "test-key"
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store, err := db.OpenStore(context.Background(), db.DriverMemory, "", db.PoolConfig{}, logger)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	srv, err := server.New(cfg, store, "test", logger)
+	if err != nil {
+		t.Fatalf("failed to build server: %v", err)
+	}
+	return srv.Handler()
+}
+
+// newClient starts handler on an httptest.Server and returns a client for it
+// that retries without waiting long.
+func newClient(t *testing.T, handler http.Handler) *client.Client {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	c, err := client.New(ts.URL, client.WithRetries(3, time.Millisecond))
+	if err != nil {
+		t.Fatalf("client.New() error = %v", err)
+	}
+	return c
+}
+
+func TestNotesCRUD(t *testing.T) {
+	c := newClient(t, newAPI(t))
+	ctx := context.Background()
+
+	created, err := c.CreateNote(ctx, &models.CreateNoteRequest{Title: "Æthelstan", Content: "First king of the English, 927–939.", Tags: []string{"history"}})
+	if err != nil {
+		t.Fatalf("CreateNote() error = %v", err)
+	}
+	if created.ID == 0 || created.Title != "Æthelstan" {
+		t.Fatalf("CreateNote() = %+v", created)
+	}
+
+	got, err := c.GetNote(ctx, int64(created.ID))
+	if err != nil {
+		t.Fatalf("GetNote() error = %v", err)
+	}
+	if got.Content != created.Content {
+		t.Fatalf("GetNote() content = %q, want %q", got.Content, created.Content)
+	}
+
+	content := "First king of all England, crowned 925."
+	updated, err := c.UpdateNote(ctx, int64(created.ID), &models.UpdateNoteRequest{Content: &content})
+	if err != nil {
+		t.Fatalf("UpdateNote() error = %v", err)
+	}
+	if updated.Content != content || updated.Title != "Æthelstan" {
+		t.Fatalf("UpdateNote() = %+v", updated)
+	}
+
+	notes, err := c.ListNotes(ctx)
+	if err != nil {
+		t.Fatalf("ListNotes() error = %v", err)
+	}
+	if len(notes) != 1 || notes[0].ID != created.ID {
+		t.Fatalf("ListNotes() = %+v, want the created note", notes)
+	}
+
+	if err := c.DeleteNote(ctx, int64(created.ID)); err != nil {
+		t.Fatalf("DeleteNote() error = %v", err)
+	}
+	if _, err := c.GetNote(ctx, int64(created.ID)); !client.IsNotFound(err) {
+		t.Fatalf("GetNote() after delete error = %v, want not found", err)
+	}
+}
+
+func TestTodosCRUD(t *testing.T) {
+	c := newClient(t, newAPI(t))
+	ctx := context.Background()
+
+	created, err := c.CreateTodo(ctx, &models.CreateTodoRequest{Title: "Revise", Description: "Norse sagas"})
+	if err != nil {
+		t.Fatalf("CreateTodo() error = %v", err)
+	}
+
+	completed := true
+	updated, err := c.UpdateTodo(ctx, created.ID, &models.UpdateTodoRequest{Completed: &completed})
+	if err != nil {
+		t.Fatalf("UpdateTodo() error = %v", err)
+	}
+	if !updated.Completed || updated.Title != "Revise" {
+		t.Fatalf("UpdateTodo() = %+v", updated)
+	}
+
+	got, err := c.GetTodo(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetTodo() error = %v", err)
+	}
+	if !got.Completed {
+		t.Fatalf("GetTodo() = %+v, want completed", got)
+	}
+
+	todos, err := c.ListTodos(ctx)
+	if err != nil {
+		t.Fatalf("ListTodos() error = %v", err)
+	}
+	if len(todos) != 1 {
+		t.Fatalf("ListTodos() returned %d todos, want 1", len(todos))
+	}
+
+	if err := c.DeleteTodo(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteTodo() error = %v", err)
+	}
+	if _, err := c.GetTodo(ctx, created.ID); !client.IsNotFound(err) {
+		t.Fatalf("GetTodo() after delete error = %v, want not found", err)
+	}
+}
+
+// TestQuizTurn checks the conversation round trip. The test key cannot reach
+// the LLM, so the reply is the service's fallback message, but it is still
+// appended to the conversation the client sent.
+func TestQuizTurn(t *testing.T) {
+	c := newClient(t, newAPI(t))
+	ctx := context.Background()
+
+	if _, err := c.CreateNote(ctx, &models.CreateNoteRequest{Title: "Snorri", Content: "Snorri Sturluson wrote the Prose Edda."}); err != nil {
+		t.Fatalf("CreateNote() error = %v", err)
+	}
+
+	sent := []models.Message{{Role: "user", Content: "Quiz me on the Eddas"}}
+	messages, err := c.QuizTurn(ctx, sent)
+	if err != nil {
+		t.Fatalf("QuizTurn() error = %v", err)
+	}
+	if len(messages) != 2 || messages[0] != sent[0] {
+		t.Fatalf("QuizTurn() = %+v, want the sent message and a reply", messages)
+	}
+	if messages[1].Role != "assistant" || messages[1].Content == "" {
+		t.Fatalf("QuizTurn() reply = %+v, want an assistant message", messages[1])
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	c := newClient(t, newAPI(t))
+	ctx := context.Background()
+
+	_, err := c.GetNote(ctx, 404)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("GetNote() error = %v, want a 404 APIError", err)
+	}
+	if !errors.Is(err, client.ErrNotFound) || errors.Is(err, client.ErrBadRequest) {
+		t.Fatalf("GetNote() error = %v, want it to match only ErrNotFound", err)
+	}
+
+	_, err = c.CreateNote(ctx, &models.CreateNoteRequest{Title: "Empty"})
+	if !errors.Is(err, client.ErrBadRequest) {
+		t.Fatalf("CreateNote() error = %v, want ErrBadRequest", err)
+	}
+	if !errors.As(err, &apiErr) || apiErr.Message != "content is required" {
+		t.Fatalf("CreateNote() error = %v, want the API's validation message", err)
+	}
+
+	_, err = c.CreateTodo(ctx, &models.CreateTodoRequest{Title: "  "})
+	if !errors.Is(err, client.ErrBadRequest) {
+		t.Fatalf("CreateTodo() error = %v, want ErrBadRequest", err)
+	}
+}
+
+// flaky answers the first failures requests with status, then passes
+// requests to the API.
+type flaky struct {
+	api      http.Handler
+	status   int
+	failures int32
+	requests atomic.Int32
+}
+
+func (f *flaky) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.requests.Add(1) <= f.failures {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(f.status)
+		w.Write([]byte(`{"error":"try again"}`))
+		return
+	}
+	f.api.ServeHTTP(w, r)
+}
+
+func TestRetries(t *testing.T) {
+	for _, status := range []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusTooManyRequests} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			f := &flaky{api: newAPI(t), status: status, failures: 2}
+			c := newClient(t, f)
+
+			if _, err := c.ListNotes(context.Background()); err != nil {
+				t.Fatalf("ListNotes() error = %v", err)
+			}
+			if got := f.requests.Load(); got != 3 {
+				t.Fatalf("server saw %d requests, want 3", got)
+			}
+		})
+	}
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		f := &flaky{api: newAPI(t), status: http.StatusServiceUnavailable, failures: 10}
+		c := newClient(t, f)
+
+		_, err := c.ListTodos(context.Background())
+		if !errors.Is(err, client.ErrServerError) {
+			t.Fatalf("ListTodos() error = %v, want ErrServerError", err)
+		}
+		if got := f.requests.Load(); got != 4 {
+			t.Fatalf("server saw %d requests, want 4", got)
+		}
+	})
+
+	t.Run("does not retry creates", func(t *testing.T) {
+		f := &flaky{api: newAPI(t), status: http.StatusServiceUnavailable, failures: 1}
+		c := newClient(t, f)
+
+		_, err := c.CreateNote(context.Background(), &models.CreateNoteRequest{Content: "Once only"})
+		if !errors.Is(err, client.ErrServerError) {
+			t.Fatalf("CreateNote() error = %v, want ErrServerError", err)
+		}
+		if got := f.requests.Load(); got != 1 {
+			t.Fatalf("server saw %d requests, want 1", got)
+		}
+	})
+
+	t.Run("does not retry other 5xx", func(t *testing.T) {
+		f := &flaky{api: newAPI(t), status: http.StatusInternalServerError, failures: 1}
+		c := newClient(t, f)
+
+		if _, err := c.ListNotes(context.Background()); !errors.Is(err, client.ErrServerError) {
+			t.Fatalf("ListNotes() error = %v, want ErrServerError", err)
+		}
+		if got := f.requests.Load(); got != 1 {
+			t.Fatalf("server saw %d requests, want 1", got)
+		}
+	})
+}
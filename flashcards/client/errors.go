@@ -0,0 +1,66 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Sentinel errors matched by APIError.Is, so callers can write
+// errors.Is(err, client.ErrNotFound) without inspecting status codes.
+var (
+	ErrBadRequest  = &APIError{StatusCode: http.StatusBadRequest}
+	ErrNotFound    = &APIError{StatusCode: http.StatusNotFound}
+	ErrServerError = &APIError{StatusCode: http.StatusInternalServerError}
+)
+
+// maxErrorBody caps how much of an unexpected error body is kept in the message.
+const maxErrorBody = 4 << 10
+
+// APIError is returned for any non-2xx response. Message carries the
+// "error" field of the API's JSON error body, or the raw body if the
+// response was not in that shape (e.g. from a proxy).
+type APIError struct {
+	StatusCode int
+	Message    string
+	Method     string
+	Path       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s: %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Is matches the sentinel errors by status code; any 5xx matches ErrServerError.
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	if !ok {
+		return false
+	}
+	if t == ErrServerError {
+		return e.StatusCode >= 500
+	}
+	return t.StatusCode == e.StatusCode
+}
+
+// IsNotFound reports whether err is an API error for a missing resource.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+func newAPIError(method, path string, res *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: res.StatusCode, Method: method, Path: path}
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+	var payload struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && payload.Error != "" {
+		apiErr.Message = payload.Error
+	} else {
+		apiErr.Message = string(body)
+	}
+	return apiErr
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+
+	"go-ai-eng-flashcards/models"
+)
+
+// ImportFile is a file to upload for import: a Markdown document or a zip
+// archive of them.
+type ImportFile struct {
+	Name string
+	Data []byte
+}
+
+// ImportOptions controls how Markdown documents are split into notes. Zero
+// values use the server defaults.
+type ImportOptions struct {
+	// Split is "auto", "heading" or "separator".
+	Split string
+	// HeadingLevel is the deepest heading that starts a new note.
+	HeadingLevel int
+}
+
+// ImportMarkdown uploads files and creates notes from them. Imports are not
+// retried, since a repeated upload would create the notes twice.
+func (c *Client) ImportMarkdown(ctx context.Context, files []ImportFile, opts ImportOptions) (*models.ImportResult, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for _, file := range files {
+		part, err := form.CreateFormFile("files", file.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", file.Name, err)
+		}
+		if _, err := part.Write(file.Data); err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", file.Name, err)
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	query := url.Values{}
+	if opts.Split != "" {
+		query.Set("split", opts.Split)
+	}
+	if opts.HeadingLevel > 0 {
+		query.Set("heading_level", fmt.Sprint(opts.HeadingLevel))
+	}
+	path := "/api/v1/notes/import"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var result models.ImportResult
+	if err := c.send(ctx, http.MethodPost, path, form.FormDataContentType(), body.Bytes(), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
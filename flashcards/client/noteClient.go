@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go-ai-eng-flashcards/models"
+)
+
+// ListNotes returns all notes, newest first.
+func (c *Client) ListNotes(ctx context.Context) ([]*models.Note, error) {
+	var notes []*models.Note
+	if err := c.do(ctx, http.MethodGet, "/api/v1/notes", nil, &notes); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// GetNote returns the note with the given ID.
+func (c *Client) GetNote(ctx context.Context, id int64) (*models.Note, error) {
+	var note models.Note
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/notes/%d", id), nil, &note); err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// CreateNote creates a note. Create requests are never retried, so a
+// transient failure cannot produce a duplicate.
+func (c *Client) CreateNote(ctx context.Context, req *models.CreateNoteRequest) (*models.Note, error) {
+	var note models.Note
+	if err := c.do(ctx, http.MethodPost, "/api/v1/notes", req, &note); err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// UpdateNote applies req to the note with the given ID and returns the result.
+func (c *Client) UpdateNote(ctx context.Context, id int64, req *models.UpdateNoteRequest) (*models.Note, error) {
+	var note models.Note
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/v1/notes/%d", id), req, &note); err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// DeleteNote deletes the note with the given ID.
+func (c *Client) DeleteNote(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/v1/notes/%d", id), nil, nil)
+}
@@ -0,0 +1,27 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"go-ai-eng-flashcards/models"
+)
+
+type quizConversation struct {
+	Messages []models.Message `json:"messages"`
+}
+
+// QuizTurn sends the conversation so far and returns it with the next
+// assistant message appended. Pass no messages to start a new quiz. Quiz
+// turns are not retried because each one is a billed LLM call.
+func (c *Client) QuizTurn(ctx context.Context, messages []models.Message) ([]models.Message, error) {
+	if messages == nil {
+		messages = []models.Message{}
+	}
+
+	var res quizConversation
+	if err := c.do(ctx, http.MethodPost, "/api/v1/quiz", quizConversation{Messages: messages}, &res); err != nil {
+		return nil, err
+	}
+	return res.Messages, nil
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go-ai-eng-flashcards/models"
+)
+
+// ListTodos returns all todos, newest first.
+func (c *Client) ListTodos(ctx context.Context) ([]*models.Todo, error) {
+	var todos []*models.Todo
+	if err := c.do(ctx, http.MethodGet, "/api/v1/todos", nil, &todos); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// GetTodo returns the todo with the given ID.
+func (c *Client) GetTodo(ctx context.Context, id int) (*models.Todo, error) {
+	var todo models.Todo
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/todos/%d", id), nil, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// CreateTodo creates a todo. Create requests are never retried.
+func (c *Client) CreateTodo(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
+	var todo models.Todo
+	if err := c.do(ctx, http.MethodPost, "/api/v1/todos", req, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// UpdateTodo applies req to the todo with the given ID and returns the result.
+func (c *Client) UpdateTodo(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
+	var todo models.Todo
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/v1/todos/%d", id), req, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// DeleteTodo deletes the todo with the given ID.
+func (c *Client) DeleteTodo(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/v1/todos/%d", id), nil, nil)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"go-ai-eng-flashcards/client"
+	"go-ai-eng-flashcards/config"
+	"go-ai-eng-flashcards/db"
+	"go-ai-eng-flashcards/importer"
+	"go-ai-eng-flashcards/models"
+	"go-ai-eng-flashcards/services"
+)
+
+// backend is what the subcommands talk to. *client.Client satisfies it for
+// the remote API; localBackend calls the service layer in-process.
+type backend interface {
+	ListNotes(ctx context.Context) ([]*models.Note, error)
+	GetNote(ctx context.Context, id int64) (*models.Note, error)
+	CreateNote(ctx context.Context, req *models.CreateNoteRequest) (*models.Note, error)
+	UpdateNote(ctx context.Context, id int64, req *models.UpdateNoteRequest) (*models.Note, error)
+	DeleteNote(ctx context.Context, id int64) error
+	ImportMarkdown(ctx context.Context, files []client.ImportFile, opts client.ImportOptions) (*models.ImportResult, error)
+
+	ListTodos(ctx context.Context) ([]*models.Todo, error)
+	GetTodo(ctx context.Context, id int) (*models.Todo, error)
+	CreateTodo(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error)
+	UpdateTodo(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error)
+	DeleteTodo(ctx context.Context, id int) error
+
+	QuizTurn(ctx context.Context, messages []models.Message) ([]models.Message, error)
+}
+
+// localBackend runs the services directly against the storage configured by
+// the usual server settings (STORAGE_DRIVER, DB_URL, GEMINI_API_KEY, ...).
+type localBackend struct {
+	notes *services.NoteService
+	todos *services.TodoService
+	quiz  *services.QuizService
+	store *db.Store
+}
+
+func newLocalBackend(ctx context.Context) (*localBackend, error) {
+	cfg, err := config.Load(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Service logs would interleave with command output, so only surface problems.
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	store, err := db.OpenStore(ctx, cfg.StorageDriver, cfg.DatabaseURL, db.PoolConfig{
+		MaxOpenConns:    2,
+		MaxIdleConns:    1,
+		ConnMaxLifetime: cfg.DBConnMaxLifetime,
+		ConnMaxIdleTime: cfg.DBConnMaxIdleTime,
+	}, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	noteService := services.NewNoteService(store.Notes, store.UnitOfWork, services.ContentPolicy{
+		MaxContentBytes: cfg.NoteMaxContentBytes,
+		MaxContentChars: cfg.NoteMaxContentChars,
+		AllowHTML:       cfg.NoteAllowHTML,
+	}, logger)
+	quizService, err := services.NewQuizService(cfg.GeminiAPIKey, noteService, logger)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	return &localBackend{
+		notes: noteService,
+		todos: services.NewTodoService(store.Todos, store.UnitOfWork),
+		quiz:  quizService,
+		store: store,
+	}, nil
+}
+
+func (b *localBackend) Close() error {
+	return b.store.Close()
+}
+
+func (b *localBackend) ListNotes(ctx context.Context) ([]*models.Note, error) {
+	return b.notes.GetAllNotes(ctx)
+}
+
+func (b *localBackend) GetNote(ctx context.Context, id int64) (*models.Note, error) {
+	return b.notes.GetNoteByID(ctx, id)
+}
+
+func (b *localBackend) CreateNote(ctx context.Context, req *models.CreateNoteRequest) (*models.Note, error) {
+	return b.notes.CreateNote(ctx, req)
+}
+
+func (b *localBackend) UpdateNote(ctx context.Context, id int64, req *models.UpdateNoteRequest) (*models.Note, error) {
+	return b.notes.UpdateNote(ctx, id, req)
+}
+
+func (b *localBackend) DeleteNote(ctx context.Context, id int64) error {
+	return b.notes.DeleteNote(ctx, id)
+}
+
+func (b *localBackend) ImportMarkdown(ctx context.Context, files []client.ImportFile, opts client.ImportOptions) (*models.ImportResult, error) {
+	mdOpts := importer.DefaultMarkdownOptions
+	mode, err := importer.ParseSplitMode(opts.Split)
+	if err != nil {
+		return nil, err
+	}
+	mdOpts.Split = mode
+	if opts.HeadingLevel > 0 {
+		mdOpts.HeadingLevel = opts.HeadingLevel
+	}
+
+	budget := importer.NewBudget()
+	var parsed []importer.File
+	for _, file := range files {
+		if !importer.IsZip(file.Name) {
+			parsed = append(parsed, importer.File{Name: file.Name, Data: file.Data})
+			continue
+		}
+		expanded, err := importer.ExpandZip(file.Data, budget)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		parsed = append(parsed, expanded...)
+	}
+
+	notes, skipped := importer.SplitMarkdownFiles(parsed, mdOpts)
+	result, err := b.notes.ImportNotes(ctx, notes)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range skipped {
+		result.Add(models.ImportItemResult{Source: file.Name, Status: models.ImportFailed, Error: "not a Markdown file"})
+	}
+	return result, nil
+}
+
+func (b *localBackend) ListTodos(ctx context.Context) ([]*models.Todo, error) {
+	return b.todos.GetAllTodos(ctx)
+}
+
+func (b *localBackend) GetTodo(ctx context.Context, id int) (*models.Todo, error) {
+	return b.todos.GetTodoByID(ctx, id)
+}
+
+func (b *localBackend) CreateTodo(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
+	return b.todos.CreateTodo(ctx, req)
+}
+
+func (b *localBackend) UpdateTodo(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
+	return b.todos.UpdateTodo(ctx, id, req)
+}
+
+func (b *localBackend) DeleteTodo(ctx context.Context, id int) error {
+	return b.todos.DeleteTodo(ctx, id)
+}
+
+func (b *localBackend) QuizTurn(ctx context.Context, messages []models.Message) ([]models.Message, error) {
+	return b.quiz.GenerateQuizTurn(ctx, messages), nil
+}
@@ -0,0 +1,116 @@
+// Command flashcards is a command-line client for the flashcards API. It
+// manages notes and todos and runs quizzes and full-screen study sessions,
+// either against a running server or, with -local, directly against the
+// configured storage.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go-ai-eng-flashcards/client"
+)
+
+const usage = `Usage: flashcards [-api URL | -local] <command> [arguments]
+
+Commands:
+  notes ls                  list notes
+  notes show <id>           print one note
+  notes add <content>       create a note ("-" reads the content from stdin)
+  notes edit <id> <content> replace a note's content ("-" reads stdin)
+  notes rm <id>             delete a note
+  notes import [-split auto|heading|separator] [-heading-level N] <file.md|file.zip>...
+
+  todos ls                  list todos
+  todos show <id>           print one todo
+  todos add -title T [-description D]
+  todos edit <id> [-title T] [-description D] [-done | -undone]
+  todos rm <id>             delete a todo
+
+  quiz                      start an interactive quiz on your notes
+  study [-demo]             full-screen study session: flip and grade cards,
+                            chat with the quiz; -demo adds sample notes if
+                            there are none
+
+Flags:
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "flashcards:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) error {
+	defaultAPI := os.Getenv("FLASHCARDS_API_URL")
+	if defaultAPI == "" {
+		defaultAPI = "http://localhost:8080"
+	}
+
+	fs := flag.NewFlagSet("flashcards", flag.ContinueOnError)
+	apiURL := fs.String("api", defaultAPI, "base URL of the flashcards API (env FLASHCARDS_API_URL)")
+	local := fs.Bool("local", false, "use the configured storage directly instead of the API")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("no command given")
+	}
+
+	var b backend
+	if *local {
+		lb, err := newLocalBackend(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to open local storage: %w", err)
+		}
+		defer lb.Close()
+		b = lb
+	} else {
+		c, err := client.New(*apiURL)
+		if err != nil {
+			return err
+		}
+		b = c
+	}
+
+	cmd := &command{backend: b, stdin: stdin, stdout: stdout}
+	rest := fs.Args()[1:]
+	switch fs.Arg(0) {
+	case "notes":
+		return cmd.notes(ctx, rest)
+	case "todos":
+		return cmd.todos(ctx, rest)
+	case "quiz":
+		return cmd.quiz(ctx)
+	case "study":
+		return cmd.study(ctx, rest)
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown command %q", fs.Arg(0))
+	}
+}
+
+// command carries what every subcommand needs.
+type command struct {
+	backend backend
+	stdin   io.Reader
+	stdout  io.Writer
+}
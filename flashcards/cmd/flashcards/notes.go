@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"go-ai-eng-flashcards/client"
+	"go-ai-eng-flashcards/models"
+)
+
+func (c *command) notes(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("notes: expected ls, show, add, edit, rm or import")
+	}
+
+	switch args[0] {
+	case "ls":
+		notes, err := c.backend.ListNotes(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tUPDATED\tTITLE\tCONTENT")
+		for _, note := range notes {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", note.ID, note.UpdatedAt.Format("2006-01-02 15:04"), summarize(note.Title, 30), summarize(note.Content, 50))
+		}
+		return w.Flush()
+
+	case "show":
+		id, err := noteID(args[1:])
+		if err != nil {
+			return err
+		}
+		note, err := c.backend.GetNote(ctx, id)
+		if err != nil {
+			return err
+		}
+		if note.Title != "" {
+			fmt.Fprintf(c.stdout, "# %s\n\n", note.Title)
+		}
+		fmt.Fprintln(c.stdout, note.Content)
+		if len(note.Tags) > 0 {
+			fmt.Fprintf(c.stdout, "\ntags: %s\n", strings.Join(note.Tags, ", "))
+		}
+		return nil
+
+	case "add":
+		if len(args) != 2 {
+			return fmt.Errorf("notes add: expected <content>")
+		}
+		content, err := c.readArg(args[1])
+		if err != nil {
+			return err
+		}
+		note, err := c.backend.CreateNote(ctx, &models.CreateNoteRequest{Content: content})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "created note %d\n", note.ID)
+		return nil
+
+	case "edit":
+		if len(args) != 3 {
+			return fmt.Errorf("notes edit: expected <id> <content>")
+		}
+		id, err := noteID(args[1:2])
+		if err != nil {
+			return err
+		}
+		content, err := c.readArg(args[2])
+		if err != nil {
+			return err
+		}
+		note, err := c.backend.UpdateNote(ctx, id, &models.UpdateNoteRequest{Content: &content})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "updated note %d\n", note.ID)
+		return nil
+
+	case "rm":
+		id, err := noteID(args[1:])
+		if err != nil {
+			return err
+		}
+		if err := c.backend.DeleteNote(ctx, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "deleted note %d\n", id)
+		return nil
+
+	case "import":
+		return c.importNotes(ctx, args[1:])
+
+	default:
+		return fmt.Errorf("notes: unknown subcommand %q", args[0])
+	}
+}
+
+// readArg returns arg, or all of stdin when arg is "-".
+func (c *command) readArg(arg string) (string, error) {
+	if arg != "-" {
+		return arg, nil
+	}
+	data, err := io.ReadAll(c.stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin: %w", err)
+	}
+	return strings.TrimRight(string(data), "\n"), nil
+}
+
+func noteID(args []string) (int64, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("expected a single note id")
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid note id %q", args[0])
+	}
+	return id, nil
+}
+
+// summarize flattens text onto one line and truncates it to at most n runes.
+func summarize(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+func (c *command) importNotes(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("notes import", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	split := fs.String("split", "", "auto, heading or separator")
+	level := fs.Int("heading-level", 0, "deepest heading that starts a new note")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("notes import: %w", err)
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("notes import: expected one or more .md or .zip files")
+	}
+
+	var files []client.ImportFile
+	for _, name := range fs.Args() {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		files = append(files, client.ImportFile{Name: filepath.Base(name), Data: data})
+	}
+
+	result, err := c.backend.ImportMarkdown(ctx, files, client.ImportOptions{Split: *split, HeadingLevel: *level})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tNOTE\tSOURCE\tDETAIL")
+	for _, item := range result.Items {
+		id, detail := "", item.Title
+		if item.NoteID != 0 {
+			id = strconv.Itoa(item.NoteID)
+		}
+		if item.Error != "" {
+			detail = item.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", item.Status, id, item.Source, summarize(detail, 50))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "\n%d created, %d failed\n", result.Created, result.Failed)
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+
+	"go-ai-eng-flashcards/models"
+)
+
+// quiz runs a quiz conversation on the terminal. Each line typed is sent as
+// the user's answer; /quit or end of input ends the session.
+func (c *command) quiz(ctx context.Context) error {
+	fmt.Fprintln(c.stdout, "Starting quiz. Type /quit to stop.")
+
+	messages, err := c.backend.QuizTurn(ctx, nil)
+	if err != nil {
+		return err
+	}
+	c.printReply(messages)
+
+	scanner := bufio.NewScanner(c.stdin)
+	for {
+		fmt.Fprint(c.stdout, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(c.stdout)
+			return scanner.Err()
+		}
+
+		answer := strings.TrimSpace(scanner.Text())
+		if answer == "" {
+			continue
+		}
+		if answer == "/quit" {
+			return nil
+		}
+
+		messages = append(messages, models.Message{Role: "user", Content: answer})
+		messages, err = c.backend.QuizTurn(ctx, messages)
+		if err != nil {
+			return err
+		}
+		c.printReply(messages)
+	}
+}
+
+func (c *command) printReply(messages []models.Message) {
+	if len(messages) == 0 {
+		return
+	}
+	last := messages[len(messages)-1]
+	if last.Role == "user" {
+		return
+	}
+	fmt.Fprintf(c.stdout, "\n%s\n\n", strings.TrimSpace(last.Content))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"go-ai-eng-flashcards/models"
+	"go-ai-eng-flashcards/tui"
+)
+
+// demoNotes are created by "study -demo" when there is nothing to study yet,
+// so a fresh in-memory store has a deck to show.
+var demoNotes = []string{
+	"What is a goroutine?\nA function running concurrently with others, scheduled by the Go runtime on a small number of OS threads.",
+	"What does a nil channel do in a select?\nIts case is never chosen: sends and receives on a nil channel block forever.",
+	"What is the zero value of a map?\nnil. Reading from it works, writing to it panics.",
+	"What does defer evaluate immediately?\nThe arguments of the deferred call; the call itself runs when the surrounding function returns.",
+	"How do you wrap an error in Go?\nfmt.Errorf with the %w verb, then inspect it with errors.Is or errors.As.",
+}
+
+func (c *command) study(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("study", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	demo := fs.Bool("demo", false, "add sample notes first if there are none")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("study: %w", err)
+	}
+
+	if *demo {
+		if err := c.seedDemoNotes(ctx); err != nil {
+			return err
+		}
+	}
+	return tui.Run(ctx, c.backend)
+}
+
+func (c *command) seedDemoNotes(ctx context.Context) error {
+	notes, err := c.backend.ListNotes(ctx)
+	if err != nil {
+		return err
+	}
+	if len(notes) > 0 {
+		return nil
+	}
+	for _, content := range demoNotes {
+		if _, err := c.backend.CreateNote(ctx, &models.CreateNoteRequest{Content: content}); err != nil {
+			return fmt.Errorf("failed to create demo note: %w", err)
+		}
+	}
+	return nil
+}
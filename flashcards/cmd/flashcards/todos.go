@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"go-ai-eng-flashcards/models"
+)
+
+func (c *command) todos(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("todos: expected ls, show, add, edit or rm")
+	}
+
+	switch args[0] {
+	case "ls":
+		todos, err := c.backend.ListTodos(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tDONE\tTITLE")
+		for _, todo := range todos {
+			done := " "
+			if todo.Completed {
+				done = "x"
+			}
+			fmt.Fprintf(w, "%d\t[%s]\t%s\n", todo.ID, done, summarize(todo.Title, 60))
+		}
+		return w.Flush()
+
+	case "show":
+		id, err := todoID(args[1:])
+		if err != nil {
+			return err
+		}
+		todo, err := c.backend.GetTodo(ctx, id)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "%s\n\n%s\n", todo.Title, todo.Description)
+		return nil
+
+	case "add":
+		fs := flag.NewFlagSet("todos add", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		title := fs.String("title", "", "todo title")
+		description := fs.String("description", "", "todo description")
+		if err := fs.Parse(args[1:]); err != nil {
+			return fmt.Errorf("todos add: %w", err)
+		}
+		todo, err := c.backend.CreateTodo(ctx, &models.CreateTodoRequest{Title: *title, Description: *description})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "created todo %d\n", todo.ID)
+		return nil
+
+	case "edit":
+		if len(args) < 2 {
+			return fmt.Errorf("todos edit: expected <id> and at least one flag")
+		}
+		id, err := todoID(args[1:2])
+		if err != nil {
+			return err
+		}
+
+		fs := flag.NewFlagSet("todos edit", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		title := fs.String("title", "", "new title")
+		description := fs.String("description", "", "new description")
+		done := fs.Bool("done", false, "mark completed")
+		undone := fs.Bool("undone", false, "mark not completed")
+		if err := fs.Parse(args[2:]); err != nil {
+			return fmt.Errorf("todos edit: %w", err)
+		}
+
+		// Only send the fields that were actually given on the command line.
+		req := &models.UpdateTodoRequest{}
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "title":
+				req.Title = title
+			case "description":
+				req.Description = description
+			case "done":
+				req.Completed = done
+			case "undone":
+				completed := !*undone
+				req.Completed = &completed
+			}
+		})
+
+		todo, err := c.backend.UpdateTodo(ctx, id, req)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "updated todo %d\n", todo.ID)
+		return nil
+
+	case "rm":
+		id, err := todoID(args[1:])
+		if err != nil {
+			return err
+		}
+		if err := c.backend.DeleteTodo(ctx, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "deleted todo %d\n", id)
+		return nil
+
+	default:
+		return fmt.Errorf("todos: unknown subcommand %q", args[0])
+	}
+}
+
+func todoID(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("expected a single todo id")
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid todo id %q", args[0])
+	}
+	return id, nil
+}
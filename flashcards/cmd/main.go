@@ -1,91 +1,142 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"go-ai-eng-flashcards/config"
 	"go-ai-eng-flashcards/db"
-	"go-ai-eng-flashcards/handlers"
-	"go-ai-eng-flashcards/services"
+	"go-ai-eng-flashcards/metrics"
+	"go-ai-eng-flashcards/server"
+	"go-ai-eng-flashcards/tracing"
+	"go-ai-eng-flashcards/watcher"
 
-	"github.com/gorilla/mux"
-	"github.com/rs/cors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
+// version is overridden at build time with -ldflags "-X main.version=...".
+var version = "dev"
+
 func main() {
-	cfg := config.Load()
 	logger := config.NewLogger()
 
-	if cfg.DatabaseURL == "" {
-		logger.Error("DB_URL environment variable is required")
-		return
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		logger.Error("Failed to load configuration", slog.Any("error", err))
+		os.Exit(1)
 	}
+	logger.Info("Configuration loaded", slog.Any("config", cfg))
 
-	todoRepo, err := db.NewPostgresTodoRepository(cfg.DatabaseURL)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TracingExporter, cfg.ServiceName)
 	if err != nil {
-		logger.Error("Failed to initialize database", slog.Any("error", err))
+		logger.Error("Failed to initialize tracing", slog.Any("error", err))
 		return
 	}
-	defer todoRepo.Close()
-
-	noteRepo, err := db.NewPostgresNoteRepository(cfg.DatabaseURL, logger)
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Error("Failed to flush traces", slog.Any("error", err))
+		}
+	}()
+
+	store, err := db.OpenStore(ctx, cfg.StorageDriver, cfg.DatabaseURL, db.PoolConfig{
+		MaxOpenConns:    cfg.DBMaxOpenConns,
+		MaxIdleConns:    cfg.DBMaxIdleConns,
+		ConnMaxLifetime: cfg.DBConnMaxLifetime,
+		ConnMaxIdleTime: cfg.DBConnMaxIdleTime,
+	}, logger)
 	if err != nil {
-		logger.Error("Failed to initialize note database", slog.Any("error", err))
+		logger.Error("Failed to initialize storage", slog.Any("error", err))
 		return
 	}
-	defer noteRepo.Close()
-
-	todoService := services.NewTodoService(todoRepo)
-	todoHandler := handlers.NewTodoHandler(todoService)
-
-	noteService := services.NewNoteService(noteRepo, logger)
-	noteHandler := handlers.NewNoteHandler(noteService, logger)
+	defer func() {
+		logger.Info("Closing storage")
+		if err := store.Close(); err != nil {
+			logger.Error("Failed to close storage", slog.Any("error", err))
+		}
+	}()
+	if store.Pool != nil {
+		if err := metrics.RegisterDBStats(store.Pool); err != nil {
+			logger.Error("Failed to register database pool metrics", slog.Any("error", err))
+			return
+		}
+	}
 
-	quizService, err := services.NewQuizService(cfg.GeminiAPIKey, noteService, logger)
+	srv, err := server.New(cfg, store, version, logger)
 	if err != nil {
-		logger.Error("Failed to initialize quiz service", slog.Any("error", err))
+		logger.Error("Failed to initialize server", slog.Any("error", err))
 		return
 	}
-	quizHandler := handlers.NewQuizHandler(quizService, logger)
-
-	router := mux.NewRouter()
-
-	router.Use(jsonMiddleware)
 
-	todoHandler.RegisterRoutes(router)
-	noteHandler.RegisterRoutes(router)
-	quizHandler.RegisterRoutes(router)
-
-	router.HandleFunc("/health", healthCheckHandler).Methods("GET")
-
-	addr := ":" + cfg.Port
-	fmt.Printf("Server starting on port %s\n", cfg.Port)
-
-	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
-		AllowCredentials: true,
-	})
+	// The watcher is stopped before storage is closed, since it writes notes.
+	if cfg.NotesWatchDir != "" {
+		notesWatcher, err := watcher.New(cfg.NotesWatchDir, cfg.NotesWatchDebounce, srv.Notes, logger)
+		if err != nil {
+			logger.Error("Failed to initialize notes watcher", slog.Any("error", err))
+			return
+		}
+		watchCtx, stopWatching := context.WithCancel(ctx)
+		watchDone := make(chan struct{})
+		go func() {
+			defer close(watchDone)
+			if err := notesWatcher.Run(watchCtx); err != nil {
+				logger.Error("Notes watcher failed", slog.Any("error", err))
+			}
+		}()
+		defer func() {
+			stopWatching()
+			<-watchDone
+		}()
+	}
 
-	handler := c.Handler(router)
+	handler := otelhttp.NewHandler(srv.Handler(), "http.server")
+
+	httpServer := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
 
-	if err := http.ListenAndServe(addr, handler); err != nil {
-		logger.Error("Server failed to start", slog.Any("error", err))
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("Server starting", slog.String("addr", httpServer.Addr))
+		serverErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Server failed to start", slog.Any("error", err))
+		}
 		return
+	case <-ctx.Done():
+		stop()
+		logger.Info("Shutdown signal received, draining in-flight requests", slog.Duration("timeout", cfg.ShutdownTimeout))
 	}
-}
 
-func jsonMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		next.ServeHTTP(w, r)
-	})
-}
+	// Shutdown stops accepting connections and waits for in-flight requests,
+	// including slow quiz turns waiting on the LLM, to finish or for the
+	// deadline to pass. The deferred Close calls then release the repositories.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Server did not shut down cleanly", slog.Any("error", err))
+		return
+	}
 
-func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status": "healthy"}`))
+	logger.Info("Server stopped")
 }
@@ -1,43 +1,430 @@
 package config
 
 import (
-	"log"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+const redacted = "[REDACTED]"
+
+// Storage drivers accepted by STORAGE_DRIVER; they match the db package's driver names.
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+// Config holds every setting the server needs. Values are resolved in
+// increasing order of precedence: defaults, the optional YAML config file,
+// environment variables (including a local .env file) and finally CLI flags.
 type Config struct {
-	DatabaseURL  string
-	Port         string
-	GeminiAPIKey string
+	StorageDriver string `yaml:"storage_driver"`
+
+	DatabaseURL  string `yaml:"db_url"`
+	Port         string `yaml:"port"`
+	GeminiAPIKey string `yaml:"gemini_api_key"`
+
+	TracingExporter string `yaml:"tracing_exporter"`
+	ServiceName     string `yaml:"service_name"`
+
+	ReadTimeout       time.Duration `yaml:"http_read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"http_read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"http_write_timeout"`
+	IdleTimeout       time.Duration `yaml:"http_idle_timeout"`
+	MaxHeaderBytes    int           `yaml:"http_max_header_bytes"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+
+	ReadinessCheckLLM bool          `yaml:"readiness_check_llm"`
+	ReadinessLLMCache time.Duration `yaml:"readiness_llm_cache"`
+	DebugEndpoints    bool          `yaml:"debug_endpoints"`
+
+	DBMaxOpenConns    int           `yaml:"db_max_open_conns"`
+	DBMaxIdleConns    int           `yaml:"db_max_idle_conns"`
+	DBConnMaxLifetime time.Duration `yaml:"db_conn_max_lifetime"`
+	DBConnMaxIdleTime time.Duration `yaml:"db_conn_max_idle_time"`
+
+	CORSAllowedOrigins   []string      `yaml:"cors_allowed_origins"`
+	CORSAllowedMethods   []string      `yaml:"cors_allowed_methods"`
+	CORSAllowedHeaders   []string      `yaml:"cors_allowed_headers"`
+	CORSAllowCredentials bool          `yaml:"cors_allow_credentials"`
+	CORSMaxAge           time.Duration `yaml:"cors_max_age"`
+
+	ServeFrontend bool `yaml:"serve_frontend"`
+	LegacyRoutes  bool `yaml:"legacy_routes"`
+
+	NoteMaxContentBytes int  `yaml:"note_max_content_bytes"`
+	NoteMaxContentChars int  `yaml:"note_max_content_chars"`
+	NoteAllowHTML       bool `yaml:"note_allow_html"`
+
+	NotesWatchDir      string        `yaml:"notes_watch_dir"`
+	NotesWatchDebounce time.Duration `yaml:"notes_watch_debounce"`
 }
 
-func Load() *Config {
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found or error loading .env file")
+// setting describes one configuration value: the environment variable and
+// CLI flag it can be set from and the Config field it is stored in.
+type setting struct {
+	env    string
+	flag   string
+	usage  string
+	target any
+}
+
+// Default returns a Config populated with the built-in defaults.
+func Default() *Config {
+	return &Config{
+		StorageDriver: StoragePostgres,
+
+		Port: "8080",
+
+		TracingExporter: "none",
+		ServiceName:     "flashcards-api",
+
+		ReadTimeout:       15 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+		// Quiz turns wait on the LLM, so the write timeout has to cover a slow completion.
+		WriteTimeout:    90 * time.Second,
+		IdleTimeout:     120 * time.Second,
+		MaxHeaderBytes:  1 << 20,
+		ShutdownTimeout: 30 * time.Second,
+
+		ReadinessLLMCache: 5 * time.Minute,
+
+		DBMaxOpenConns:    10,
+		DBMaxIdleConns:    5,
+		DBConnMaxLifetime: 30 * time.Minute,
+		DBConnMaxIdleTime: 5 * time.Minute,
+
+		CORSAllowedOrigins: []string{"*"},
+		CORSAllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		CORSAllowedHeaders: []string{"Content-Type", "Authorization"},
+		CORSMaxAge:         10 * time.Minute,
+
+		LegacyRoutes: true,
+
+		NoteMaxContentBytes: 1 << 20,
+		NoteAllowHTML:       true,
+
+		NotesWatchDebounce: 500 * time.Millisecond,
+	}
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{"STORAGE_DRIVER", "storage-driver", "storage backend: postgres or memory", &c.StorageDriver},
+		{"DB_URL", "db-url", "PostgreSQL connection string", &c.DatabaseURL},
+		{"PORT", "port", "port to listen on", &c.Port},
+		{"GEMINI_API_KEY", "gemini-api-key", "Gemini API key", &c.GeminiAPIKey},
+
+		{"TRACING_EXPORTER", "tracing-exporter", "trace exporter: none, stdout or otlp", &c.TracingExporter},
+		{"OTEL_SERVICE_NAME", "service-name", "service name attached to spans", &c.ServiceName},
+
+		{"HTTP_READ_TIMEOUT", "http-read-timeout", "maximum duration for reading a request", &c.ReadTimeout},
+		{"HTTP_READ_HEADER_TIMEOUT", "http-read-header-timeout", "maximum duration for reading request headers", &c.ReadHeaderTimeout},
+		{"HTTP_WRITE_TIMEOUT", "http-write-timeout", "maximum duration for writing a response", &c.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", "http-idle-timeout", "maximum keep-alive idle time", &c.IdleTimeout},
+		{"HTTP_MAX_HEADER_BYTES", "http-max-header-bytes", "maximum size of request headers", &c.MaxHeaderBytes},
+		{"SHUTDOWN_TIMEOUT", "shutdown-timeout", "time allowed to drain in-flight requests on shutdown", &c.ShutdownTimeout},
+
+		{"READINESS_CHECK_LLM", "readiness-check-llm", "include an LLM call in /readyz", &c.ReadinessCheckLLM},
+		{"READINESS_LLM_CACHE", "readiness-llm-cache", "how long an LLM readiness result is reused", &c.ReadinessLLMCache},
+		{"DEBUG_ENDPOINTS", "debug-endpoints", "serve unauthenticated diagnostics such as /debug/db", &c.DebugEndpoints},
+
+		{"DB_MAX_OPEN_CONNS", "db-max-open-conns", "maximum open database connections", &c.DBMaxOpenConns},
+		{"DB_MAX_IDLE_CONNS", "db-max-idle-conns", "maximum idle database connections kept in the pool", &c.DBMaxIdleConns},
+		{"DB_CONN_MAX_LIFETIME", "db-conn-max-lifetime", "maximum time a database connection is reused", &c.DBConnMaxLifetime},
+		{"DB_CONN_MAX_IDLE_TIME", "db-conn-max-idle-time", "maximum time a database connection may sit idle", &c.DBConnMaxIdleTime},
+
+		{"CORS_ALLOWED_ORIGINS", "cors-allowed-origins", "comma-separated allowed origins; supports * and https://*.example.com", &c.CORSAllowedOrigins},
+		{"CORS_ALLOWED_METHODS", "cors-allowed-methods", "comma-separated allowed methods", &c.CORSAllowedMethods},
+		{"CORS_ALLOWED_HEADERS", "cors-allowed-headers", "comma-separated allowed request headers", &c.CORSAllowedHeaders},
+		{"CORS_ALLOW_CREDENTIALS", "cors-allow-credentials", "allow cookies and auth headers on cross-origin requests", &c.CORSAllowCredentials},
+		{"CORS_MAX_AGE", "cors-max-age", "how long browsers may cache a preflight response", &c.CORSMaxAge},
+
+		{"SERVE_FRONTEND", "serve-frontend", "serve the embedded flashcards-app build at / (API stays under /api)", &c.ServeFrontend},
+		{"LEGACY_ROUTES", "legacy-routes", "keep deprecated unversioned API paths as aliases of /api/v1", &c.LegacyRoutes},
+
+		{"NOTE_MAX_CONTENT_BYTES", "note-max-content-bytes", "largest note content in bytes; 0 for no limit", &c.NoteMaxContentBytes},
+		{"NOTE_MAX_CONTENT_CHARS", "note-max-content-chars", "largest note content in characters; 0 for no limit", &c.NoteMaxContentChars},
+		{"NOTE_ALLOW_HTML", "note-allow-html", "allow raw HTML tags in note Markdown", &c.NoteAllowHTML},
+
+		{"NOTES_WATCH_DIR", "notes-watch-dir", "directory of Markdown files to keep notes in sync with; empty disables", &c.NotesWatchDir},
+		{"NOTES_WATCH_DEBOUNCE", "notes-watch-debounce", "quiet period after file changes before syncing", &c.NotesWatchDebounce},
+	}
+}
+
+// Load builds the configuration from defaults, an optional YAML file, the
+// environment and the given command-line arguments, then validates it. All
+// problems are reported together so they can be fixed in one go.
+func Load(args []string) (*Config, error) {
+	// A missing .env file is normal outside local development.
+	_ = godotenv.Load()
+
+	config := Default()
+	settings := config.settings()
+
+	fs := flag.NewFlagSet("flashcards", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file (env CONFIG_FILE)")
+	flagValues := make(map[string]string)
+	for _, s := range settings {
+		name := s.flag
+		fs.Func(name, fmt.Sprintf("%s (env %s)", s.usage, s.env), func(value string) error {
+			flagValues[name] = value
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var errs []error
+
+	if *configFile != "" {
+		if err := config.loadFile(*configFile); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok && value != "" {
+			if err := setValue(s.target, value); err != nil {
+				errs = append(errs, fmt.Errorf("environment variable %s: %w", s.env, err))
+			}
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := flagValues[s.flag]; ok {
+			if err := setValue(s.target, value); err != nil {
+				errs = append(errs, fmt.Errorf("flag -%s: %w", s.flag, err))
+			}
+		}
 	}
 
-	config := &Config{
-		DatabaseURL:  getEnv("DB_URL"),
-		Port:         getEnvWithDefault("PORT", "8080"),
-		GeminiAPIKey: getEnv("GEMINI_API_KEY"),
+	errs = append(errs, config.validate()...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
 	}
 
-	return config
+	return config, nil
 }
 
-func getEnv(key string) string {
-	value := os.Getenv(key)
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) validate() []error {
+	var errs []error
+
+	switch c.StorageDriver {
+	case StoragePostgres:
+		if c.DatabaseURL == "" {
+			errs = append(errs, errors.New("DB_URL is required when STORAGE_DRIVER is postgres"))
+		} else if _, err := url.Parse(c.DatabaseURL); err != nil {
+			errs = append(errs, errors.New("DB_URL is not a valid connection URL"))
+		}
+	case StorageMemory:
+	default:
+		errs = append(errs, fmt.Errorf("STORAGE_DRIVER must be one of postgres, memory, got %q", c.StorageDriver))
+	}
+
+	if c.GeminiAPIKey == "" {
+		errs = append(errs, errors.New("GEMINI_API_KEY is required"))
+	}
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be a number between 1 and 65535, got %q", c.Port))
+	}
+
+	switch c.TracingExporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER must be one of none, stdout, otlp, got %q", c.TracingExporter))
+	}
+
+	timeouts := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"HTTP_READ_TIMEOUT", c.ReadTimeout},
+		{"HTTP_READ_HEADER_TIMEOUT", c.ReadHeaderTimeout},
+		{"HTTP_WRITE_TIMEOUT", c.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", c.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", c.ShutdownTimeout},
+		{"NOTES_WATCH_DEBOUNCE", c.NotesWatchDebounce},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %s", timeout.name, timeout.value))
+		}
+	}
+
+	if c.MaxHeaderBytes <= 0 {
+		errs = append(errs, fmt.Errorf("HTTP_MAX_HEADER_BYTES must be positive, got %d", c.MaxHeaderBytes))
+	}
+
+	if c.ReadinessLLMCache < 0 {
+		errs = append(errs, fmt.Errorf("READINESS_LLM_CACHE cannot be negative, got %s", c.ReadinessLLMCache))
+	}
+
+	if c.DBMaxOpenConns <= 0 {
+		errs = append(errs, fmt.Errorf("DB_MAX_OPEN_CONNS must be positive, got %d", c.DBMaxOpenConns))
+	}
+	if c.DBMaxIdleConns < 0 || c.DBMaxIdleConns > c.DBMaxOpenConns {
+		errs = append(errs, fmt.Errorf("DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS, got %d", c.DBMaxIdleConns))
+	}
+	if c.DBConnMaxLifetime < 0 || c.DBConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("DB_CONN_MAX_LIFETIME and DB_CONN_MAX_IDLE_TIME cannot be negative"))
+	}
+
+	if c.NoteMaxContentBytes < 0 || c.NoteMaxContentChars < 0 {
+		errs = append(errs, errors.New("NOTE_MAX_CONTENT_BYTES and NOTE_MAX_CONTENT_CHARS cannot be negative"))
+	}
+
+	errs = append(errs, c.validateCORS()...)
+
+	return errs
+}
+
+func (c *Config) validateCORS() []error {
+	var errs []error
+
+	if len(c.CORSAllowedOrigins) == 0 {
+		errs = append(errs, errors.New("CORS_ALLOWED_ORIGINS must list at least one origin"))
+	}
+
+	for _, origin := range c.CORSAllowedOrigins {
+		if origin == "*" {
+			// Browsers refuse credentialed responses with a wildcard origin.
+			if c.CORSAllowCredentials {
+				errs = append(errs, errors.New("CORS_ALLOW_CREDENTIALS cannot be enabled when CORS_ALLOWED_ORIGINS contains *"))
+			}
+			continue
+		}
+		if strings.Count(origin, "*") > 1 {
+			errs = append(errs, fmt.Errorf("CORS origin %q may contain at most one wildcard", origin))
+			continue
+		}
+		u, err := url.Parse(strings.Replace(origin, "*", "wildcard", 1))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+			errs = append(errs, fmt.Errorf("CORS origin %q must look like https://app.example.com or https://*.example.com", origin))
+		}
+	}
+
+	if c.CORSMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("CORS_MAX_AGE cannot be negative, got %s", c.CORSMaxAge))
+	}
+
+	return errs
+}
+
+// LogValue implements slog.LogValuer so the configuration can be logged at
+// startup without leaking the API key or the database password.
+func (c *Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("storage_driver", c.StorageDriver),
+		slog.String("db_url", redactURL(c.DatabaseURL)),
+		slog.String("port", c.Port),
+		slog.String("gemini_api_key", redactSecret(c.GeminiAPIKey)),
+		slog.String("tracing_exporter", c.TracingExporter),
+		slog.String("service_name", c.ServiceName),
+		slog.Duration("http_read_timeout", c.ReadTimeout),
+		slog.Duration("http_read_header_timeout", c.ReadHeaderTimeout),
+		slog.Duration("http_write_timeout", c.WriteTimeout),
+		slog.Duration("http_idle_timeout", c.IdleTimeout),
+		slog.Int("http_max_header_bytes", c.MaxHeaderBytes),
+		slog.Duration("shutdown_timeout", c.ShutdownTimeout),
+		slog.Bool("readiness_check_llm", c.ReadinessCheckLLM),
+		slog.Duration("readiness_llm_cache", c.ReadinessLLMCache),
+		slog.Bool("debug_endpoints", c.DebugEndpoints),
+		slog.Int("db_max_open_conns", c.DBMaxOpenConns),
+		slog.Int("db_max_idle_conns", c.DBMaxIdleConns),
+		slog.Duration("db_conn_max_lifetime", c.DBConnMaxLifetime),
+		slog.Duration("db_conn_max_idle_time", c.DBConnMaxIdleTime),
+		slog.Any("cors_allowed_origins", c.CORSAllowedOrigins),
+		slog.Any("cors_allowed_methods", c.CORSAllowedMethods),
+		slog.Any("cors_allowed_headers", c.CORSAllowedHeaders),
+		slog.Bool("cors_allow_credentials", c.CORSAllowCredentials),
+		slog.Duration("cors_max_age", c.CORSMaxAge),
+		slog.Bool("serve_frontend", c.ServeFrontend),
+		slog.Bool("legacy_routes", c.LegacyRoutes),
+		slog.Int("note_max_content_bytes", c.NoteMaxContentBytes),
+		slog.Int("note_max_content_chars", c.NoteMaxContentChars),
+		slog.Bool("note_allow_html", c.NoteAllowHTML),
+		slog.String("notes_watch_dir", c.NotesWatchDir),
+		slog.Duration("notes_watch_debounce", c.NotesWatchDebounce),
+	)
+}
+
+func setValue(target any, value string) error {
+	switch t := target.(type) {
+	case *string:
+		*t = value
+	case *int:
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		*t = number
+	case *bool:
+		flag, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		*t = flag
+	case *[]string:
+		var values []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		*t = values
+	case *time.Duration:
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		*t = duration
+	default:
+		return fmt.Errorf("unsupported setting type %T", target)
+	}
+	return nil
+}
+
+func redactSecret(value string) string {
 	if value == "" {
-		panic("Required environment variable not set: " + key)
+		return ""
 	}
-	return value
+	return redacted
 }
 
-func getEnvWithDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+func redactURL(value string) string {
+	if value == "" {
+		return ""
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return redacted
 	}
-	return defaultValue
+	return u.Redacted()
 }
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadCORS(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name: "wildcard without credentials",
+			args: []string{"-cors-allowed-origins=*"},
+		},
+		{
+			name: "explicit origins with credentials",
+			args: []string{"-cors-allowed-origins=https://app.example.com,https://*.example.com", "-cors-allow-credentials=true"},
+		},
+		{
+			name:    "wildcard with credentials",
+			args:    []string{"-cors-allowed-origins=*", "-cors-allow-credentials=true"},
+			wantErr: "CORS_ALLOW_CREDENTIALS cannot be enabled when CORS_ALLOWED_ORIGINS contains *",
+		},
+		{
+			name:    "two wildcards",
+			args:    []string{"-cors-allowed-origins=https://*.*.example.com"},
+			wantErr: "may contain at most one wildcard",
+		},
+		{
+			name:    "origin with a path",
+			args:    []string{"-cors-allowed-origins=https://app.example.com/"},
+			wantErr: "must look like https://app.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"-storage-driver=memory", "-gemini-api-key=test-key"}, tt.args...)
+			_, err := Load(args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// PoolConfig tunes the connection pool shared by every repository.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// Open creates the single *sql.DB used by all repositories and verifies the
+// connection. The caller owns the pool and must Close it on shutdown.
+func Open(ctx context.Context, databaseURL string, pool PoolConfig, logger *slog.Logger) (*sql.DB, error) {
+	logger.Info("Attempting to open database connection pool",
+		slog.Int("max_open_conns", pool.MaxOpenConns),
+		slog.Int("max_idle_conns", pool.MaxIdleConns),
+		slog.Duration("conn_max_lifetime", pool.ConnMaxLifetime),
+		slog.Duration("conn_max_idle_time", pool.ConnMaxIdleTime),
+	)
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		logger.Error("Failed to open database", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+
+	logger.Info("Pinging database to verify connection")
+	if err := db.PingContext(ctx); err != nil {
+		logger.Error("Failed to ping database", slog.Any("error", err))
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	// lib/pq always talks UTF-8 to the server; a database with another
+	// encoding would reject or garble non-ASCII notes.
+	var encoding string
+	if err := db.QueryRowContext(ctx, "SHOW server_encoding").Scan(&encoding); err != nil {
+		logger.Error("Failed to read database encoding", slog.Any("error", err))
+		db.Close()
+		return nil, fmt.Errorf("failed to read database encoding: %w", err)
+	}
+	if encoding != "UTF8" {
+		logger.Error("Database encoding is not UTF8", slog.String("encoding", encoding))
+		db.Close()
+		return nil, fmt.Errorf("database encoding is %s; notes need a UTF8 database", encoding)
+	}
+
+	logger.Info("Database connection pool established successfully")
+	return db, nil
+}
@@ -0,0 +1,234 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"go-ai-eng-flashcards/metrics"
+	"go-ai-eng-flashcards/models"
+	"go-ai-eng-flashcards/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentedNoteRepository wraps a NoteRepository and records a span plus
+// the duration and outcome of every call.
+type instrumentedNoteRepository struct {
+	next NoteRepository
+}
+
+// NewInstrumentedNoteRepository decorates repo with tracing spans and query duration metrics.
+func NewInstrumentedNoteRepository(repo NoteRepository) NoteRepository {
+	return &instrumentedNoteRepository{next: repo}
+}
+
+func (r *instrumentedNoteRepository) CreateNote(ctx context.Context, note *models.Note) (err error) {
+	ctx, done := instrument(ctx, "notes", "CreateNote")
+	defer func() { done(err) }()
+	return r.next.CreateNote(ctx, note)
+}
+
+func (r *instrumentedNoteRepository) GetNoteById(ctx context.Context, id int64) (note *models.Note, err error) {
+	ctx, done := instrument(ctx, "notes", "GetNoteById", attribute.Int64("note.id", id))
+	defer func() { done(err) }()
+	return r.next.GetNoteById(ctx, id)
+}
+
+func (r *instrumentedNoteRepository) GetAllNotes(ctx context.Context) (notes []*models.Note, err error) {
+	ctx, done := instrument(ctx, "notes", "GetAllNotes")
+	defer func() { done(err) }()
+	return r.next.GetAllNotes(ctx)
+}
+
+func (r *instrumentedNoteRepository) EachNoteIncludingDeleted(ctx context.Context, fn func(*models.Note) error) (err error) {
+	ctx, done := instrument(ctx, "notes", "EachNoteIncludingDeleted")
+	defer func() { done(err) }()
+	return r.next.EachNoteIncludingDeleted(ctx, fn)
+}
+
+func (r *instrumentedNoteRepository) UpdateNote(ctx context.Context, id int64, updates map[string]any) (err error) {
+	ctx, done := instrument(ctx, "notes", "UpdateNote", attribute.Int64("note.id", id))
+	defer func() { done(err) }()
+	return r.next.UpdateNote(ctx, id, updates)
+}
+
+func (r *instrumentedNoteRepository) DeleteNote(ctx context.Context, id int64) (err error) {
+	ctx, done := instrument(ctx, "notes", "DeleteNote", attribute.Int64("note.id", id))
+	defer func() { done(err) }()
+	return r.next.DeleteNote(ctx, id)
+}
+
+func (r *instrumentedNoteRepository) Ping(ctx context.Context) error {
+	return r.next.Ping(ctx)
+}
+
+func (r *instrumentedNoteRepository) GetNoteBySourcePath(ctx context.Context, sourcePath string) (note *models.Note, err error) {
+	ctx, done := instrument(ctx, "notes", "GetNoteBySourcePath")
+	defer func() { done(err) }()
+	return r.next.GetNoteBySourcePath(ctx, sourcePath)
+}
+
+func (r *instrumentedNoteRepository) ListNotesBySourcePrefix(ctx context.Context, prefix string) (notes []*models.Note, err error) {
+	ctx, done := instrument(ctx, "notes", "ListNotesBySourcePrefix")
+	defer func() { done(err) }()
+	return r.next.ListNotesBySourcePrefix(ctx, prefix)
+}
+
+func (r *instrumentedNoteRepository) SetNoteLinks(ctx context.Context, id int64, targets []int64) (err error) {
+	ctx, done := instrument(ctx, "notes", "SetNoteLinks", attribute.Int64("note.id", id), attribute.Int("note.links", len(targets)))
+	defer func() { done(err) }()
+	return r.next.SetNoteLinks(ctx, id, targets)
+}
+
+func (r *instrumentedNoteRepository) GetNoteLinks(ctx context.Context, id int64) (links *models.NoteLinks, err error) {
+	ctx, done := instrument(ctx, "notes", "GetNoteLinks", attribute.Int64("note.id", id))
+	defer func() { done(err) }()
+	return r.next.GetNoteLinks(ctx, id)
+}
+
+func (r *instrumentedNoteRepository) ListNoteLinks(ctx context.Context) (links []models.NoteLink, err error) {
+	ctx, done := instrument(ctx, "notes", "ListNoteLinks")
+	defer func() { done(err) }()
+	return r.next.ListNoteLinks(ctx)
+}
+
+func (r *instrumentedNoteRepository) EachNoteLinkIncludingDeleted(ctx context.Context, fn func(models.NoteLink) error) (err error) {
+	ctx, done := instrument(ctx, "notes", "EachNoteLinkIncludingDeleted")
+	defer func() { done(err) }()
+	return r.next.EachNoteLinkIncludingDeleted(ctx, fn)
+}
+
+func (r *instrumentedNoteRepository) SetNoteChunks(ctx context.Context, id int64, chunks []models.NoteChunk) (err error) {
+	ctx, done := instrument(ctx, "notes", "SetNoteChunks", attribute.Int64("note.id", id), attribute.Int("note.chunks", len(chunks)))
+	defer func() { done(err) }()
+	return r.next.SetNoteChunks(ctx, id, chunks)
+}
+
+func (r *instrumentedNoteRepository) GetNoteChunks(ctx context.Context, id int64) (chunks []models.NoteChunk, err error) {
+	ctx, done := instrument(ctx, "notes", "GetNoteChunks", attribute.Int64("note.id", id))
+	defer func() { done(err) }()
+	return r.next.GetNoteChunks(ctx, id)
+}
+
+func (r *instrumentedNoteRepository) ListNoteChunks(ctx context.Context) (chunks []models.NoteChunk, err error) {
+	ctx, done := instrument(ctx, "notes", "ListNoteChunks")
+	defer func() { done(err) }()
+	return r.next.ListNoteChunks(ctx)
+}
+
+func (r *instrumentedNoteRepository) RestoreNotes(ctx context.Context, notes []*models.Note) (err error) {
+	ctx, done := instrument(ctx, "notes", "RestoreNotes", attribute.Int("notes.count", len(notes)))
+	defer func() { done(err) }()
+	return r.next.RestoreNotes(ctx, notes)
+}
+
+// instrumentedTodoRepository wraps a TodoRepository and records a span plus
+// the duration and outcome of every call.
+type instrumentedTodoRepository struct {
+	next TodoRepository
+}
+
+// NewInstrumentedTodoRepository decorates repo with tracing spans and query duration metrics.
+func NewInstrumentedTodoRepository(repo TodoRepository) TodoRepository {
+	return &instrumentedTodoRepository{next: repo}
+}
+
+func (r *instrumentedTodoRepository) CreateTodo(ctx context.Context, todo *models.Todo) (err error) {
+	ctx, done := instrument(ctx, "todos", "CreateTodo")
+	defer func() { done(err) }()
+	return r.next.CreateTodo(ctx, todo)
+}
+
+func (r *instrumentedTodoRepository) GetTodoByID(ctx context.Context, id int) (todo *models.Todo, err error) {
+	ctx, done := instrument(ctx, "todos", "GetTodoByID", attribute.Int("todo.id", id))
+	defer func() { done(err) }()
+	return r.next.GetTodoByID(ctx, id)
+}
+
+func (r *instrumentedTodoRepository) GetAllTodos(ctx context.Context) (todos []*models.Todo, err error) {
+	ctx, done := instrument(ctx, "todos", "GetAllTodos")
+	defer func() { done(err) }()
+	return r.next.GetAllTodos(ctx)
+}
+
+func (r *instrumentedTodoRepository) EachTodo(ctx context.Context, fn func(*models.Todo) error) (err error) {
+	ctx, done := instrument(ctx, "todos", "EachTodo")
+	defer func() { done(err) }()
+	return r.next.EachTodo(ctx, fn)
+}
+
+func (r *instrumentedTodoRepository) UpdateTodo(ctx context.Context, id int, updates map[string]any) (err error) {
+	ctx, done := instrument(ctx, "todos", "UpdateTodo", attribute.Int("todo.id", id))
+	defer func() { done(err) }()
+	return r.next.UpdateTodo(ctx, id, updates)
+}
+
+func (r *instrumentedTodoRepository) DeleteTodo(ctx context.Context, id int) (err error) {
+	ctx, done := instrument(ctx, "todos", "DeleteTodo", attribute.Int("todo.id", id))
+	defer func() { done(err) }()
+	return r.next.DeleteTodo(ctx, id)
+}
+
+func (r *instrumentedTodoRepository) Ping(ctx context.Context) error {
+	return r.next.Ping(ctx)
+}
+
+func (r *instrumentedTodoRepository) RestoreTodos(ctx context.Context, todos []*models.Todo) (err error) {
+	ctx, done := instrument(ctx, "todos", "RestoreTodos", attribute.Int("todos.count", len(todos)))
+	defer func() { done(err) }()
+	return r.next.RestoreTodos(ctx, todos)
+}
+
+// instrumentedUnitOfWork wraps a UnitOfWork with a span around the whole
+// transaction and hands fn instrumented repositories.
+type instrumentedUnitOfWork struct {
+	next UnitOfWork
+}
+
+// NewInstrumentedUnitOfWork decorates uow so the transaction and every call made inside it are traced and measured.
+func NewInstrumentedUnitOfWork(uow UnitOfWork) UnitOfWork {
+	return &instrumentedUnitOfWork{next: uow}
+}
+
+func (u *instrumentedUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) (err error) {
+	ctx, done := instrument(ctx, "unit_of_work", "Do")
+	defer func() { done(err) }()
+	return u.next.Do(ctx, func(ctx context.Context, repos Repositories) error {
+		return fn(ctx, Repositories{
+			Notes: NewInstrumentedNoteRepository(repos.Notes),
+			Todos: NewInstrumentedTodoRepository(repos.Todos),
+		})
+	})
+}
+
+func (u *instrumentedUnitOfWork) DoReadOnly(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) (err error) {
+	ctx, done := instrument(ctx, "unit_of_work", "DoReadOnly")
+	defer func() { done(err) }()
+	return u.next.DoReadOnly(ctx, func(ctx context.Context, repos Repositories) error {
+		return fn(ctx, Repositories{
+			Notes: NewInstrumentedNoteRepository(repos.Notes),
+			Todos: NewInstrumentedTodoRepository(repos.Todos),
+		})
+	})
+}
+
+// instrument starts a client span for a repository call and returns a
+// function that ends it and records the call's duration metric.
+func instrument(ctx context.Context, repository, method string, attrs ...attribute.KeyValue) (context.Context, func(error)) {
+	start := time.Now()
+	attrs = append(attrs,
+		attribute.String("db.repository", repository),
+		semconv.DBOperationName(method),
+	)
+	ctx, span := tracing.Tracer().Start(ctx, repository+"."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+
+	return ctx, func(err error) {
+		tracing.EndSpan(span, err)
+		metrics.ObserveDBQuery(repository, method, start, err)
+	}
+}
@@ -0,0 +1,624 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go-ai-eng-flashcards/models"
+)
+
+// MemoryStore holds notes and todos in process memory. It backs the
+// in-memory repositories used for local demos and development without
+// Postgres; nothing survives a restart.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	notes      map[int64]*models.Note
+	noteLinks  map[int64][]int64 // outgoing links by note ID
+	noteChunks map[int64][]models.NoteChunk
+	todos      map[int]*models.Todo
+	nextNoteID int64
+	nextTodoID int
+
+	// txMu serialises units of work so a rollback only ever undoes its own changes.
+	txMu sync.Mutex
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		notes:      make(map[int64]*models.Note),
+		noteLinks:  make(map[int64][]int64),
+		noteChunks: make(map[int64][]models.NoteChunk),
+		todos:      make(map[int]*models.Todo),
+		nextNoteID: 1,
+		nextTodoID: 1,
+	}
+}
+
+type memorySnapshot struct {
+	notes      map[int64]*models.Note
+	noteLinks  map[int64][]int64
+	noteChunks map[int64][]models.NoteChunk
+	todos      map[int]*models.Todo
+	nextNoteID int64
+	nextTodoID int
+}
+
+func (s *MemoryStore) snapshot() memorySnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := memorySnapshot{
+		notes:      make(map[int64]*models.Note, len(s.notes)),
+		noteLinks:  make(map[int64][]int64, len(s.noteLinks)),
+		noteChunks: make(map[int64][]models.NoteChunk, len(s.noteChunks)),
+		todos:      make(map[int]*models.Todo, len(s.todos)),
+		nextNoteID: s.nextNoteID,
+		nextTodoID: s.nextTodoID,
+	}
+	for id, note := range s.notes {
+		snap.notes[id] = copyNote(note)
+	}
+	for id, targets := range s.noteLinks {
+		snap.noteLinks[id] = slices.Clone(targets)
+	}
+	// Chunks are replaced, never modified in place, so sharing them is safe.
+	maps.Copy(snap.noteChunks, s.noteChunks)
+	for id, todo := range s.todos {
+		copied := *todo
+		snap.todos[id] = &copied
+	}
+	return snap
+}
+
+func (s *MemoryStore) restore(snap memorySnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.notes = snap.notes
+	s.noteLinks = snap.noteLinks
+	s.noteChunks = snap.noteChunks
+	s.todos = snap.todos
+	s.nextNoteID = snap.nextNoteID
+	s.nextTodoID = snap.nextTodoID
+}
+
+type MemoryNoteRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryNoteRepository creates a note repository backed by store.
+func NewMemoryNoteRepository(store *MemoryStore) *MemoryNoteRepository {
+	return &MemoryNoteRepository{store: store}
+}
+
+func (r *MemoryNoteRepository) CreateNote(ctx context.Context, note *models.Note) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if note.SourcePath != "" && r.findBySourcePath(note.SourcePath) != nil {
+		return fmt.Errorf("failed to create note: source path %q already exists", note.SourcePath)
+	}
+
+	now := time.Now()
+	note.ID = int(r.store.nextNoteID)
+	note.CreatedAt = now
+	note.UpdatedAt = now
+	r.store.nextNoteID++
+
+	note.Tags = cloneTags(note.Tags)
+	note.Metadata = cloneMetadata(note.Metadata)
+	r.store.notes[int64(note.ID)] = copyNote(note)
+	return nil
+}
+
+func (r *MemoryNoteRepository) GetNoteById(ctx context.Context, id int64) (*models.Note, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	note, ok := r.store.notes[id]
+	if !ok || note.DeletedAt != nil {
+		return nil, fmt.Errorf("note with id %d %w", id, ErrNoteNotFound)
+	}
+	return copyNote(note), nil
+}
+
+func (r *MemoryNoteRepository) GetAllNotes(ctx context.Context) ([]*models.Note, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	notes := make([]*models.Note, 0, len(r.store.notes))
+	for _, id := range slices.Sorted(maps.Keys(r.store.notes)) {
+		if note := r.store.notes[id]; note.DeletedAt == nil {
+			notes = append(notes, copyNote(note))
+		}
+	}
+	// Newest first, matching the Postgres ORDER BY created_at DESC.
+	slices.Reverse(notes)
+	return notes, nil
+}
+
+// EachNoteIncludingDeleted calls fn with copies of every note, taken first so
+// the store is not locked while fn writes to a slow client.
+func (r *MemoryNoteRepository) EachNoteIncludingDeleted(ctx context.Context, fn func(*models.Note) error) error {
+	r.store.mu.RLock()
+	notes := make([]*models.Note, 0, len(r.store.notes))
+	for _, id := range slices.Sorted(maps.Keys(r.store.notes)) {
+		notes = append(notes, copyNote(r.store.notes[id]))
+	}
+	r.store.mu.RUnlock()
+	slices.Reverse(notes)
+
+	for _, note := range notes {
+		if err := fn(note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryNoteRepository) UpdateNote(ctx context.Context, id int64, updates map[string]any) error {
+	if len(updates) == 0 {
+		return fmt.Errorf("no updates provided")
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	note, ok := r.store.notes[id]
+	if !ok {
+		return fmt.Errorf("no rows updated - note with id %d not found", id)
+	}
+
+	updated := copyNote(note)
+	for field, value := range updates {
+		var ok bool
+		switch field {
+		case "title":
+			updated.Title, ok = value.(string)
+		case "content":
+			updated.Content, ok = value.(string)
+		case "tags":
+			var tags []string
+			tags, ok = value.([]string)
+			updated.Tags = cloneTags(tags)
+		case "metadata":
+			var metadata map[string]any
+			metadata, ok = value.(map[string]any)
+			updated.Metadata = cloneMetadata(metadata)
+		case "deleted_at":
+			switch deletedAt := value.(type) {
+			case nil:
+				updated.DeletedAt, ok = nil, true
+			case time.Time:
+				updated.DeletedAt, ok = &deletedAt, true
+			}
+		default:
+			return fmt.Errorf("unknown note field %s", field)
+		}
+		if !ok {
+			return fmt.Errorf("invalid value for note field %s", field)
+		}
+	}
+	updated.UpdatedAt = time.Now()
+	r.store.notes[id] = updated
+	return nil
+}
+
+func (r *MemoryNoteRepository) DeleteNote(ctx context.Context, id int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.notes[id]; !ok {
+		return fmt.Errorf("no rows deleted - note with id %d not found", id)
+	}
+	delete(r.store.notes, id)
+
+	// Match the ON DELETE CASCADE on note_links and note_chunks.
+	delete(r.store.noteChunks, id)
+	delete(r.store.noteLinks, id)
+	for from, targets := range r.store.noteLinks {
+		r.store.noteLinks[from] = slices.DeleteFunc(targets, func(to int64) bool { return to == id })
+	}
+	return nil
+}
+
+func (r *MemoryNoteRepository) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (r *MemoryNoteRepository) GetNoteBySourcePath(ctx context.Context, sourcePath string) (*models.Note, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if note := r.findBySourcePath(sourcePath); note != nil {
+		return copyNote(note), nil
+	}
+	return nil, nil
+}
+
+func (r *MemoryNoteRepository) ListNotesBySourcePrefix(ctx context.Context, prefix string) ([]*models.Note, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	notes := make([]*models.Note, 0)
+	for _, note := range r.store.notes {
+		if note.SourcePath != "" && strings.HasPrefix(note.SourcePath, prefix) {
+			notes = append(notes, copyNote(note))
+		}
+	}
+	slices.SortFunc(notes, func(a, b *models.Note) int { return strings.Compare(a.SourcePath, b.SourcePath) })
+	return notes, nil
+}
+
+func (r *MemoryNoteRepository) RestoreNotes(ctx context.Context, notes []*models.Note) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, note := range notes {
+		if existing := r.findBySourcePath(note.SourcePath); note.SourcePath != "" && existing != nil && existing.ID != note.ID {
+			return fmt.Errorf("failed to restore note %d: source path %q already exists", note.ID, note.SourcePath)
+		}
+		r.store.notes[int64(note.ID)] = copyNote(note)
+		r.store.nextNoteID = max(r.store.nextNoteID, int64(note.ID)+1)
+	}
+	return nil
+}
+
+func (r *MemoryNoteRepository) ListNoteLinks(ctx context.Context) ([]models.NoteLink, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	links := make([]models.NoteLink, 0)
+	for _, from := range slices.Sorted(maps.Keys(r.store.noteLinks)) {
+		if !r.live(from) {
+			continue
+		}
+		targets := slices.Clone(r.store.noteLinks[from])
+		slices.Sort(targets)
+		for _, to := range targets {
+			if r.live(to) {
+				links = append(links, models.NoteLink{From: int(from), To: int(to)})
+			}
+		}
+	}
+	return links, nil
+}
+
+// EachNoteLinkIncludingDeleted calls fn with every note link, those of
+// soft-deleted notes included, copied first like EachNoteIncludingDeleted.
+func (r *MemoryNoteRepository) EachNoteLinkIncludingDeleted(ctx context.Context, fn func(models.NoteLink) error) error {
+	r.store.mu.RLock()
+	links := make([]models.NoteLink, 0)
+	for _, from := range slices.Sorted(maps.Keys(r.store.noteLinks)) {
+		targets := slices.Clone(r.store.noteLinks[from])
+		slices.Sort(targets)
+		for _, to := range targets {
+			links = append(links, models.NoteLink{From: int(from), To: int(to)})
+		}
+	}
+	r.store.mu.RUnlock()
+
+	for _, link := range links {
+		if err := fn(link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// live reports whether the note with id exists and is not soft-deleted.
+func (r *MemoryNoteRepository) live(id int64) bool {
+	note, ok := r.store.notes[id]
+	return ok && note.DeletedAt == nil
+}
+
+func (r *MemoryNoteRepository) findBySourcePath(sourcePath string) *models.Note {
+	for _, note := range r.store.notes {
+		if note.SourcePath == sourcePath {
+			return note
+		}
+	}
+	return nil
+}
+
+func (r *MemoryNoteRepository) SetNoteLinks(ctx context.Context, id int64, targets []int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.notes[id]; !ok {
+		return fmt.Errorf("note with id %d not found", id)
+	}
+	links := make([]int64, 0, len(targets))
+	for _, to := range targets {
+		if _, ok := r.store.notes[to]; !ok {
+			return fmt.Errorf("failed to insert note links: note with id %d not found", to)
+		}
+		if !slices.Contains(links, to) {
+			links = append(links, to)
+		}
+	}
+	r.store.noteLinks[id] = links
+	return nil
+}
+
+func (r *MemoryNoteRepository) SetNoteChunks(ctx context.Context, id int64, chunks []models.NoteChunk) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.notes[id]; !ok {
+		return fmt.Errorf("note with id %d not found", id)
+	}
+	stored := make([]models.NoteChunk, len(chunks))
+	for i, chunk := range chunks {
+		chunk.NoteID = int(id)
+		chunk.Heading = cloneTags(chunk.Heading)
+		stored[i] = chunk
+	}
+	slices.SortFunc(stored, func(a, b models.NoteChunk) int { return a.Position - b.Position })
+	r.store.noteChunks[id] = stored
+	return nil
+}
+
+func (r *MemoryNoteRepository) GetNoteChunks(ctx context.Context, id int64) ([]models.NoteChunk, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.cloneChunks(id), nil
+}
+
+func (r *MemoryNoteRepository) ListNoteChunks(ctx context.Context) ([]models.NoteChunk, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	chunks := make([]models.NoteChunk, 0)
+	for _, id := range slices.Sorted(maps.Keys(r.store.noteChunks)) {
+		if r.live(id) {
+			chunks = append(chunks, r.cloneChunks(id)...)
+		}
+	}
+	return chunks, nil
+}
+
+func (r *MemoryNoteRepository) cloneChunks(id int64) []models.NoteChunk {
+	chunks := make([]models.NoteChunk, 0, len(r.store.noteChunks[id]))
+	for _, chunk := range r.store.noteChunks[id] {
+		chunk.Heading = cloneTags(chunk.Heading)
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+func (r *MemoryNoteRepository) GetNoteLinks(ctx context.Context, id int64) (*models.NoteLinks, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if !r.live(id) {
+		return nil, fmt.Errorf("note with id %d not found", id)
+	}
+
+	links := &models.NoteLinks{NoteID: int(id), Outgoing: []int{}, Incoming: []int{}}
+	for _, to := range r.store.noteLinks[id] {
+		if r.live(to) {
+			links.Outgoing = append(links.Outgoing, int(to))
+		}
+	}
+	for from, targets := range r.store.noteLinks {
+		if r.live(from) && slices.Contains(targets, id) {
+			links.Incoming = append(links.Incoming, int(from))
+		}
+	}
+	slices.Sort(links.Outgoing)
+	slices.Sort(links.Incoming)
+	return links, nil
+}
+
+// copyNote returns a copy that shares no tags or metadata with the stored note.
+func copyNote(note *models.Note) *models.Note {
+	copied := *note
+	copied.Tags = cloneTags(note.Tags)
+	copied.Metadata = cloneMetadata(note.Metadata)
+	return &copied
+}
+
+// cloneMetadata copies the top level of metadata, turning nil into an empty
+// object like the Postgres default. Nested values are never modified in
+// place, so sharing them is safe.
+func cloneMetadata(metadata map[string]any) map[string]any {
+	if metadata == nil {
+		return map[string]any{}
+	}
+	return maps.Clone(metadata)
+}
+
+// cloneTags copies tags, turning nil into an empty list like the Postgres default.
+func cloneTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return slices.Clone(tags)
+}
+
+type MemoryTodoRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryTodoRepository creates a todo repository backed by store.
+func NewMemoryTodoRepository(store *MemoryStore) *MemoryTodoRepository {
+	return &MemoryTodoRepository{store: store}
+}
+
+func (r *MemoryTodoRepository) CreateTodo(ctx context.Context, todo *models.Todo) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	todo.ID = r.store.nextTodoID
+	todo.CreatedAt = now
+	todo.UpdatedAt = now
+	r.store.nextTodoID++
+
+	stored := *todo
+	r.store.todos[todo.ID] = &stored
+	return nil
+}
+
+func (r *MemoryTodoRepository) GetTodoByID(ctx context.Context, id int) (*models.Todo, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	todo, ok := r.store.todos[id]
+	if !ok {
+		return nil, fmt.Errorf("todo with id %d not found", id)
+	}
+	copied := *todo
+	return &copied, nil
+}
+
+func (r *MemoryTodoRepository) GetAllTodos(ctx context.Context) ([]*models.Todo, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	todos := make([]*models.Todo, 0, len(r.store.todos))
+	for _, id := range slices.Sorted(maps.Keys(r.store.todos)) {
+		copied := *r.store.todos[id]
+		todos = append(todos, &copied)
+	}
+	slices.Reverse(todos)
+	return todos, nil
+}
+
+func (r *MemoryTodoRepository) EachTodo(ctx context.Context, fn func(*models.Todo) error) error {
+	todos, err := r.GetAllTodos(ctx)
+	if err != nil {
+		return err
+	}
+	for _, todo := range todos {
+		if err := fn(todo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryTodoRepository) UpdateTodo(ctx context.Context, id int, updates map[string]any) error {
+	if len(updates) == 0 {
+		return fmt.Errorf("no updates provided")
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	todo, ok := r.store.todos[id]
+	if !ok {
+		return fmt.Errorf("todo with id %d not found", id)
+	}
+
+	updated := *todo
+	for field, value := range updates {
+		var ok bool
+		switch field {
+		case "title":
+			updated.Title, ok = value.(string)
+		case "description":
+			updated.Description, ok = value.(string)
+		case "completed":
+			updated.Completed, ok = value.(bool)
+		default:
+			return fmt.Errorf("unknown todo field %s", field)
+		}
+		if !ok {
+			return fmt.Errorf("invalid value for todo field %s", field)
+		}
+	}
+	updated.UpdatedAt = time.Now()
+	r.store.todos[id] = &updated
+	return nil
+}
+
+func (r *MemoryTodoRepository) DeleteTodo(ctx context.Context, id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.todos[id]; !ok {
+		return fmt.Errorf("todo with id %d not found", id)
+	}
+	delete(r.store.todos, id)
+	return nil
+}
+
+func (r *MemoryTodoRepository) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (r *MemoryTodoRepository) RestoreTodos(ctx context.Context, todos []*models.Todo) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, todo := range todos {
+		stored := *todo
+		r.store.todos[todo.ID] = &stored
+		r.store.nextTodoID = max(r.store.nextTodoID, todo.ID+1)
+	}
+	return nil
+}
+
+type MemoryUnitOfWork struct {
+	store *MemoryStore
+}
+
+// NewMemoryUnitOfWork creates a unit of work over store. Changes are applied
+// directly and undone from a snapshot if the function fails. Units of work
+// are serialised against each other, but plain repository calls made outside
+// one can interleave and would be lost on rollback; that is acceptable for
+// the demo and development use the in-memory store is meant for.
+func NewMemoryUnitOfWork(store *MemoryStore) *MemoryUnitOfWork {
+	return &MemoryUnitOfWork{store: store}
+}
+
+func (u *MemoryUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) (err error) {
+	u.store.txMu.Lock()
+	defer u.store.txMu.Unlock()
+
+	snap := u.store.snapshot()
+	defer func() {
+		if p := recover(); p != nil {
+			u.store.restore(snap)
+			panic(p)
+		}
+		if err != nil {
+			u.store.restore(snap)
+		}
+	}()
+
+	return fn(ctx, Repositories{
+		Notes: NewMemoryNoteRepository(u.store),
+		Todos: NewMemoryTodoRepository(u.store),
+	})
+}
+
+// DoReadOnly runs fn on a copy of the store taken between units of work, so
+// the store is only locked while it is copied, not while fn runs.
+func (u *MemoryUnitOfWork) DoReadOnly(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error {
+	u.store.txMu.Lock()
+	snap := u.store.snapshot()
+	u.store.txMu.Unlock()
+
+	view := &MemoryStore{
+		notes:      snap.notes,
+		noteLinks:  snap.noteLinks,
+		noteChunks: snap.noteChunks,
+		todos:      snap.todos,
+		nextNoteID: snap.nextNoteID,
+		nextTodoID: snap.nextTodoID,
+	}
+	return fn(ctx, Repositories{
+		Notes: NewMemoryNoteRepository(view),
+		Todos: NewMemoryTodoRepository(view),
+	})
+}
@@ -1,57 +1,100 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"go-ai-eng-flashcards/models"
 	"log/slog"
+	"maps"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+// ErrNoteNotFound is wrapped by the error GetNoteById returns when there is
+// no note with the ID, or it is soft-deleted.
+var ErrNoteNotFound = errors.New("not found")
+
 type NoteRepository interface {
-	CreateNote(note *models.Note) error
-	GetNoteById(id int64) (*models.Note, error)
-	GetAllNotes() ([]*models.Note, error)
-	UpdateNote(id int64, updates map[string]any) error
-	DeleteNote(id int64) error
-	Close() error
+	CreateNote(ctx context.Context, note *models.Note) error
+	GetNoteById(ctx context.Context, id int64) (*models.Note, error)
+	GetAllNotes(ctx context.Context) ([]*models.Note, error)
+	UpdateNote(ctx context.Context, id int64, updates map[string]any) error
+	DeleteNote(ctx context.Context, id int64) error
+	Ping(ctx context.Context) error
+
+	// GetNoteBySourcePath returns the note imported from sourcePath, or nil
+	// without an error if there is none. Unlike the other reads it also
+	// returns soft-deleted notes.
+	GetNoteBySourcePath(ctx context.Context, sourcePath string) (*models.Note, error)
+	// ListNotesBySourcePrefix returns every note whose source path starts
+	// with prefix, soft-deleted ones included, ordered by source path.
+	ListNotesBySourcePrefix(ctx context.Context, prefix string) ([]*models.Note, error)
+	// SetNoteLinks replaces the notes that the note with id links to.
+	SetNoteLinks(ctx context.Context, id int64, targets []int64) error
+	GetNoteLinks(ctx context.Context, id int64) (*models.NoteLinks, error)
+	ListNoteLinks(ctx context.Context) ([]models.NoteLink, error)
+
+	// EachNoteIncludingDeleted and EachNoteLinkIncludingDeleted call fn with
+	// every note and note link, soft-deleted notes and their links included,
+	// one at a time as they are read, so exports are complete and never held
+	// in memory. An error from fn stops the read and is returned.
+	EachNoteIncludingDeleted(ctx context.Context, fn func(*models.Note) error) error
+	EachNoteLinkIncludingDeleted(ctx context.Context, fn func(models.NoteLink) error) error
+
+	// SetNoteChunks replaces the chunks of the note with id.
+	SetNoteChunks(ctx context.Context, id int64, chunks []models.NoteChunk) error
+	// GetNoteChunks returns the chunks of the note with id in order.
+	GetNoteChunks(ctx context.Context, id int64) ([]models.NoteChunk, error)
+	// ListNoteChunks returns the chunks of every note that is not
+	// soft-deleted, ordered by note and position.
+	ListNoteChunks(ctx context.Context) ([]models.NoteChunk, error)
+
+	// RestoreNotes writes notes exactly as given, IDs and timestamps
+	// included, replacing any note with the same ID. New notes created
+	// afterwards get IDs above the highest restored one.
+	RestoreNotes(ctx context.Context, notes []*models.Note) error
+}
+
+// noteUpdates whitelists the columns UpdateNote may set.
+var noteUpdates = updateBuilder{
+	table:       "flashcards.notes",
+	columns:     []string{"title", "content", "tags", "metadata", "deleted_at"},
+	touchColumn: "updated_at",
+	idColumn:    "id",
 }
 
 type PostgresNoteRepository struct {
-	db     *sql.DB
+	db     DBTX
+	stmts  *StmtCache
 	logger *slog.Logger
 }
 
-func NewPostgresNoteRepository(dbUrl string, logger *slog.Logger) (*PostgresNoteRepository, error) {
-	logger.Info("Attempting to open database connection")
-	db, err := sql.Open("postgres", dbUrl)
-	if err != nil {
-		logger.Error("Failed to open database", slog.Any("error", err))
-		return nil, fmt.Errorf("failed to open database: %w", err)
-	}
-
-	logger.Info("Pinging database to verify connection")
-	if err := db.Ping(); err != nil {
-		logger.Error("Failed to ping database", slog.Any("error", err))
-		return nil, fmt.Errorf("failed to ping database: %w", err)
-	}
-
-	logger.Info("Database connection established successfully")
-	return &PostgresNoteRepository{db: db, logger: logger}, nil
+// NewPostgresNoteRepository creates a note repository on the shared pool returned by Open.
+func NewPostgresNoteRepository(db *sql.DB, stmts *StmtCache, logger *slog.Logger) *PostgresNoteRepository {
+	return &PostgresNoteRepository{db: db, stmts: stmts, logger: logger}
 }
 
-func (r *PostgresNoteRepository) CreateNote(note *models.Note) error {
+func (r *PostgresNoteRepository) CreateNote(ctx context.Context, note *models.Note) error {
 	r.logger.Info("Attempting to create a new note", slog.Any("note_content", note.Content))
 	query := `
 	INSERT INTO
-		flashcards.notes (content)
-	VALUES ($1)
+		flashcards.notes (title, content, tags, metadata, source_path)
+	VALUES ($1, $2, $3, $4, NULLIF($5, ''))
 	RETURNING id, created_at, updated_at
 	`
 
-	row := r.db.QueryRow(query, note.Content)
-	err := row.Scan(&note.ID, &note.CreatedAt, &note.UpdatedAt)
+	metadata, err := encodeMetadata(note.Metadata)
+	if err != nil {
+		r.logger.Error("Failed to encode note metadata", slog.Any("error", err))
+		return err
+	}
+
+	row := r.db.QueryRowContext(ctx, query, note.Title, note.Content, pq.Array(nonNilTags(note.Tags)), metadata, note.SourcePath)
+	err = row.Scan(&note.ID, &note.CreatedAt, &note.UpdatedAt)
 	if err != nil {
 		r.logger.Error("Failed to create note", slog.Any("error", err))
 		return fmt.Errorf("failed to create note: %w", err)
@@ -61,25 +104,22 @@ func (r *PostgresNoteRepository) CreateNote(note *models.Note) error {
 	return nil
 }
 
-func (r *PostgresNoteRepository) GetNoteById(id int64) (*models.Note, error) {
+func (r *PostgresNoteRepository) GetNoteById(ctx context.Context, id int64) (*models.Note, error) {
 	r.logger.Info("Attempting to retrieve note by ID", slog.Any("note_id", id))
 	query := `
-	SELECT 
-		id, content, created_at, updated_at
+	SELECT
+		` + noteColumns + `
 	FROM
 	    flashcards.notes
 	WHERE
-	    id = $1
+	    id = $1 AND deleted_at IS NULL
 	`
 
-	note := &models.Note{}
-	row := r.db.QueryRow(query, id)
-
-	err := row.Scan(&note.ID, &note.Content, &note.CreatedAt, &note.UpdatedAt)
+	note, err := scanNote(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			r.logger.Warn("Note not found", slog.Any("note_id", id))
-			return nil, fmt.Errorf("note with id %d not found", id)
+			return nil, fmt.Errorf("note with id %d %w", id, ErrNoteNotFound)
 		}
 		r.logger.Error("Failed to get note by ID", slog.Any("note_id", id), slog.Any("error", err))
 		return nil, fmt.Errorf("failed to get note: %w", err)
@@ -89,68 +129,106 @@ func (r *PostgresNoteRepository) GetNoteById(id int64) (*models.Note, error) {
 	return note, nil
 }
 
-func (r *PostgresNoteRepository) GetAllNotes() ([]*models.Note, error) {
-	r.logger.Info("Attempting to retrieve all notes")
-	query := `
+// allNotesQuery selects every note that is not soft-deleted, newest first.
+const allNotesQuery = `
+	SELECT
+		` + noteColumns + `
+	FROM
+	    flashcards.notes
+	WHERE
+	    deleted_at IS NULL
+	ORDER BY
+	    created_at DESC
+	`
+
+// allNotesIncludingDeletedQuery selects every note, soft-deleted ones
+// included, newest first.
+const allNotesIncludingDeletedQuery = `
 	SELECT
-		id, content, created_at, updated_at
+		` + noteColumns + `
 	FROM
 	    flashcards.notes
 	ORDER BY
 	    created_at DESC
 	`
 
-	rows, err := r.db.Query(query)
+func (r *PostgresNoteRepository) GetAllNotes(ctx context.Context) ([]*models.Note, error) {
+	r.logger.Info("Attempting to retrieve all notes")
+
+	notes := make([]*models.Note, 0)
+	err := r.eachNote(ctx, allNotesQuery, func(note *models.Note) error {
+		notes = append(notes, note)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.Info("All notes retrieved successfully", slog.Any("count", len(notes)))
+	return notes, nil
+}
+
+func (r *PostgresNoteRepository) EachNoteIncludingDeleted(ctx context.Context, fn func(*models.Note) error) error {
+	r.logger.Info("Attempting to stream all notes, soft-deleted ones included")
+	return r.eachNote(ctx, allNotesIncludingDeletedQuery, fn)
+}
+
+// eachNote runs query, which selects noteColumns, and calls fn with each
+// note as its row is scanned.
+func (r *PostgresNoteRepository) eachNote(ctx context.Context, query string, fn func(*models.Note) error) error {
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		r.logger.Error("Failed to get all notes", slog.Any("error", err))
-		return nil, fmt.Errorf("failed to get all notes: %w", err)
+		return fmt.Errorf("failed to get all notes: %w", err)
 	}
 	defer rows.Close()
 
-	notes := make([]*models.Note, 0)
 	for rows.Next() {
-		note := &models.Note{}
-		err := rows.Scan(&note.ID, &note.Content, &note.CreatedAt, &note.UpdatedAt)
+		note, err := scanNote(rows)
 		if err != nil {
 			r.logger.Error("Failed to scan note", slog.Any("error", err))
-			return nil, fmt.Errorf("failed to scan note: %w", err)
+			return fmt.Errorf("failed to scan note: %w", err)
+		}
+		if err := fn(note); err != nil {
+			return err
 		}
-		notes = append(notes, note)
 	}
 
 	if err := rows.Err(); err != nil {
 		r.logger.Error("Failed to iterate notes", slog.Any("error", err))
-		return nil, fmt.Errorf("failed to iterate notes: %w", err)
+		return fmt.Errorf("failed to iterate notes: %w", err)
 	}
-
-	r.logger.Info("All notes retrieved successfully", slog.Any("count", len(notes)))
-	return notes, nil
+	return nil
 }
 
-func (r *PostgresNoteRepository) UpdateNote(id int64, updates map[string]any) error {
+func (r *PostgresNoteRepository) UpdateNote(ctx context.Context, id int64, updates map[string]any) error {
 	r.logger.Info("Attempting to update note", slog.Any("note_id", id), slog.Any("updates", updates))
-	if len(updates) == 0 {
-		r.logger.Warn("No updates provided for note", slog.Any("note_id", id))
-		return fmt.Errorf("no updates provided")
+	if tags, ok := updates["tags"].([]string); ok {
+		updates = maps.Clone(updates)
+		updates["tags"] = pq.Array(nonNilTags(tags))
 	}
-
-	query := "UPDATE flashcards.notes SET "
-	args := []any{}
-	argIndex := 1
-
-	for field, value := range updates {
-		if argIndex > 1 {
-			query += ","
+	if deletedAt, ok := updates["deleted_at"].(time.Time); ok {
+		// The column is a timestamp without a time zone, holding UTC.
+		updates = maps.Clone(updates)
+		updates["deleted_at"] = deletedAt.UTC()
+	}
+	if metadata, ok := updates["metadata"].(map[string]any); ok {
+		encoded, err := encodeMetadata(metadata)
+		if err != nil {
+			r.logger.Error("Failed to encode note metadata", slog.Any("note_id", id), slog.Any("error", err))
+			return err
 		}
-		query += fmt.Sprintf("%s = $%d", field, argIndex)
-		args = append(args, value)
-		argIndex++
+		updates = maps.Clone(updates)
+		updates["metadata"] = encoded
 	}
 
-	query += fmt.Sprintf(", updated_at = NOW() WHERE id = $%d", argIndex)
-	args = append(args, id)
+	query, args, err := noteUpdates.build(id, updates)
+	if err != nil {
+		r.logger.Warn("Rejected note update", slog.Any("note_id", id), slog.Any("error", err))
+		return err
+	}
 
-	result, err := r.db.Exec(query, args...)
+	result, err := r.stmts.exec(ctx, r.db, query, args...)
 	if err != nil {
 		r.logger.Error("Failed to update note", slog.Any("note_id", id), slog.Any("error", err))
 		return fmt.Errorf("failed to update note: %w", err)
@@ -171,11 +249,11 @@ func (r *PostgresNoteRepository) UpdateNote(id int64, updates map[string]any) er
 	return nil
 }
 
-func (r *PostgresNoteRepository) DeleteNote(id int64) error {
+func (r *PostgresNoteRepository) DeleteNote(ctx context.Context, id int64) error {
 	r.logger.Info("Attempting to delete note", slog.Any("note_id", id))
 	query := "DELETE FROM flashcards.notes WHERE id = $1"
 
-	result, err := r.db.Exec(query, id)
+	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		r.logger.Error("Failed to delete note", slog.Any("note_id", id), slog.Any("error", err))
 		return fmt.Errorf("failed to delete note: %w", err)
@@ -196,13 +274,380 @@ func (r *PostgresNoteRepository) DeleteNote(id int64) error {
 	return nil
 }
 
-func (r *PostgresNoteRepository) Close() error {
-	r.logger.Info("Closing database connection")
-	err := r.db.Close()
+func (r *PostgresNoteRepository) Ping(ctx context.Context) error {
+	if err := ping(ctx, r.db); err != nil {
+		r.logger.Error("Failed to ping database", slog.Any("error", err))
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresNoteRepository) GetNoteBySourcePath(ctx context.Context, sourcePath string) (*models.Note, error) {
+	r.logger.Info("Attempting to retrieve note by source path", slog.String("source_path", sourcePath))
+	query := `
+	SELECT
+		` + noteColumns + `
+	FROM
+	    flashcards.notes
+	WHERE
+	    source_path = $1
+	`
+
+	note, err := scanNote(r.db.QueryRowContext(ctx, query, sourcePath))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error("Failed to get note by source path", slog.String("source_path", sourcePath), slog.Any("error", err))
+		return nil, fmt.Errorf("failed to get note by source path: %w", err)
+	}
+
+	r.logger.Info("Note retrieved successfully", slog.Any("note_id", note.ID))
+	return note, nil
+}
+
+func (r *PostgresNoteRepository) ListNotesBySourcePrefix(ctx context.Context, prefix string) ([]*models.Note, error) {
+	r.logger.Info("Attempting to list notes by source path prefix", slog.String("prefix", prefix))
+	query := `
+	SELECT
+		` + noteColumns + `
+	FROM
+	    flashcards.notes
+	WHERE
+	    starts_with(source_path, $1)
+	ORDER BY
+	    source_path
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, prefix)
+	if err != nil {
+		r.logger.Error("Failed to list notes by source path prefix", slog.String("prefix", prefix), slog.Any("error", err))
+		return nil, fmt.Errorf("failed to list notes by source path prefix: %w", err)
+	}
+	defer rows.Close()
+
+	notes := make([]*models.Note, 0)
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan note", slog.Any("error", err))
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+		notes = append(notes, note)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Failed to iterate notes", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to iterate notes: %w", err)
+	}
+
+	r.logger.Info("Notes listed successfully", slog.Int("count", len(notes)))
+	return notes, nil
+}
+
+func (r *PostgresNoteRepository) SetNoteLinks(ctx context.Context, id int64, targets []int64) error {
+	r.logger.Info("Attempting to set note links", slog.Any("note_id", id), slog.Int("count", len(targets)))
+
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM flashcards.note_links WHERE from_note_id = $1", id); err != nil {
+		r.logger.Error("Failed to clear note links", slog.Any("note_id", id), slog.Any("error", err))
+		return fmt.Errorf("failed to clear note links: %w", err)
+	}
+
+	if len(targets) > 0 {
+		query := `
+		INSERT INTO
+			flashcards.note_links (from_note_id, to_note_id)
+		SELECT $1, unnest($2::integer[])
+		ON CONFLICT DO NOTHING
+		`
+		if _, err := r.db.ExecContext(ctx, query, id, pq.Array(targets)); err != nil {
+			r.logger.Error("Failed to insert note links", slog.Any("note_id", id), slog.Any("error", err))
+			return fmt.Errorf("failed to insert note links: %w", err)
+		}
+	}
+
+	r.logger.Info("Note links set successfully", slog.Any("note_id", id))
+	return nil
+}
+
+func (r *PostgresNoteRepository) SetNoteChunks(ctx context.Context, id int64, chunks []models.NoteChunk) error {
+	r.logger.Info("Attempting to set note chunks", slog.Any("note_id", id), slog.Int("count", len(chunks)))
+
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM flashcards.note_chunks WHERE note_id = $1", id); err != nil {
+		r.logger.Error("Failed to clear note chunks", slog.Any("note_id", id), slog.Any("error", err))
+		return fmt.Errorf("failed to clear note chunks: %w", err)
+	}
+
+	query := `
+	INSERT INTO
+		flashcards.note_chunks (note_id, position, heading, content)
+	VALUES ($1, $2, $3, $4)
+	`
+	for _, chunk := range chunks {
+		if _, err := r.stmts.exec(ctx, r.db, query, id, chunk.Position, pq.Array(nonNilTags(chunk.Heading)), chunk.Content); err != nil {
+			r.logger.Error("Failed to insert note chunk", slog.Any("note_id", id), slog.Int("position", chunk.Position), slog.Any("error", err))
+			return fmt.Errorf("failed to insert note chunk: %w", err)
+		}
+	}
+
+	r.logger.Info("Note chunks set successfully", slog.Any("note_id", id))
+	return nil
+}
+
+func (r *PostgresNoteRepository) GetNoteChunks(ctx context.Context, id int64) ([]models.NoteChunk, error) {
+	r.logger.Info("Attempting to retrieve note chunks", slog.Any("note_id", id))
+	query := `
+	SELECT
+		note_id, position, heading, content
+	FROM
+	    flashcards.note_chunks
+	WHERE
+	    note_id = $1
+	ORDER BY
+	    position
+	`
+	return r.queryChunks(ctx, query, id)
+}
+
+func (r *PostgresNoteRepository) ListNoteChunks(ctx context.Context) ([]models.NoteChunk, error) {
+	r.logger.Info("Attempting to list note chunks")
+	query := `
+	SELECT
+		c.note_id, c.position, c.heading, c.content
+	FROM
+	    flashcards.note_chunks c
+	    JOIN flashcards.notes n ON n.id = c.note_id
+	WHERE
+	    n.deleted_at IS NULL
+	ORDER BY
+	    c.note_id, c.position
+	`
+	return r.queryChunks(ctx, query)
+}
+
+func (r *PostgresNoteRepository) queryChunks(ctx context.Context, query string, args ...any) ([]models.NoteChunk, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to get note chunks", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to get note chunks: %w", err)
+	}
+	defer rows.Close()
+
+	chunks := make([]models.NoteChunk, 0)
+	for rows.Next() {
+		var chunk models.NoteChunk
+		if err := rows.Scan(&chunk.NoteID, &chunk.Position, pq.Array(&chunk.Heading), &chunk.Content); err != nil {
+			r.logger.Error("Failed to scan note chunk", slog.Any("error", err))
+			return nil, fmt.Errorf("failed to scan note chunk: %w", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Failed to iterate note chunks", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to iterate note chunks: %w", err)
+	}
+
+	r.logger.Info("Note chunks retrieved successfully", slog.Int("count", len(chunks)))
+	return chunks, nil
+}
+
+func (r *PostgresNoteRepository) GetNoteLinks(ctx context.Context, id int64) (*models.NoteLinks, error) {
+	r.logger.Info("Attempting to retrieve note links", slog.Any("note_id", id))
+	query := `
+	SELECT
+		ARRAY(
+			SELECT l.to_note_id FROM flashcards.note_links l JOIN flashcards.notes t ON t.id = l.to_note_id
+			WHERE l.from_note_id = n.id AND t.deleted_at IS NULL ORDER BY l.to_note_id
+		),
+		ARRAY(
+			SELECT l.from_note_id FROM flashcards.note_links l JOIN flashcards.notes f ON f.id = l.from_note_id
+			WHERE l.to_note_id = n.id AND f.deleted_at IS NULL ORDER BY l.from_note_id
+		)
+	FROM
+	    flashcards.notes n
+	WHERE
+	    n.id = $1 AND n.deleted_at IS NULL
+	`
+
+	var outgoing, incoming pq.Int64Array
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&outgoing, &incoming)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.logger.Warn("Note not found", slog.Any("note_id", id))
+			return nil, fmt.Errorf("note with id %d not found", id)
+		}
+		r.logger.Error("Failed to get note links", slog.Any("note_id", id), slog.Any("error", err))
+		return nil, fmt.Errorf("failed to get note links: %w", err)
+	}
+
+	links := &models.NoteLinks{NoteID: int(id), Outgoing: toInts(outgoing), Incoming: toInts(incoming)}
+	r.logger.Info("Note links retrieved successfully", slog.Any("note_id", id))
+	return links, nil
+}
+
+func (r *PostgresNoteRepository) ListNoteLinks(ctx context.Context) ([]models.NoteLink, error) {
+	r.logger.Info("Attempting to list all note links")
+
+	links := make([]models.NoteLink, 0)
+	query := `
+	SELECT
+		l.from_note_id, l.to_note_id
+	FROM
+	    flashcards.note_links l
+	    JOIN flashcards.notes f ON f.id = l.from_note_id
+	    JOIN flashcards.notes t ON t.id = l.to_note_id
+	WHERE
+	    f.deleted_at IS NULL AND t.deleted_at IS NULL
+	ORDER BY
+	    l.from_note_id, l.to_note_id
+	`
+	err := r.eachNoteLink(ctx, query, func(link models.NoteLink) error {
+		links = append(links, link)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.Info("Note links listed successfully", slog.Int("count", len(links)))
+	return links, nil
+}
+
+func (r *PostgresNoteRepository) EachNoteLinkIncludingDeleted(ctx context.Context, fn func(models.NoteLink) error) error {
+	r.logger.Info("Attempting to stream all note links, soft-deleted notes included")
+	query := `
+	SELECT
+		from_note_id, to_note_id
+	FROM
+	    flashcards.note_links
+	ORDER BY
+	    from_note_id, to_note_id
+	`
+	return r.eachNoteLink(ctx, query, fn)
+}
+
+// eachNoteLink runs query, which selects from and to note IDs, and calls fn
+// with each link as its row is scanned.
+func (r *PostgresNoteRepository) eachNoteLink(ctx context.Context, query string, fn func(models.NoteLink) error) error {
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
-		r.logger.Error("Failed to close database connection", slog.Any("error", err))
-		return fmt.Errorf("failed to close database: %w", err)
+		r.logger.Error("Failed to list note links", slog.Any("error", err))
+		return fmt.Errorf("failed to list note links: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var link models.NoteLink
+		if err := rows.Scan(&link.From, &link.To); err != nil {
+			r.logger.Error("Failed to scan note link", slog.Any("error", err))
+			return fmt.Errorf("failed to scan note link: %w", err)
+		}
+		if err := fn(link); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over note links", slog.Any("error", err))
+		return fmt.Errorf("error iterating over note links: %w", err)
 	}
-	r.logger.Info("Database connection closed successfully")
 	return nil
 }
+
+func (r *PostgresNoteRepository) RestoreNotes(ctx context.Context, notes []*models.Note) error {
+	r.logger.Info("Attempting to restore notes", slog.Int("count", len(notes)))
+	query := `
+	INSERT INTO
+		flashcards.notes (id, title, content, tags, metadata, source_path, created_at, updated_at, deleted_at)
+	VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9)
+	ON CONFLICT (id) DO UPDATE SET
+		title = EXCLUDED.title,
+		content = EXCLUDED.content,
+		tags = EXCLUDED.tags,
+		metadata = EXCLUDED.metadata,
+		source_path = EXCLUDED.source_path,
+		created_at = EXCLUDED.created_at,
+		updated_at = EXCLUDED.updated_at,
+		deleted_at = EXCLUDED.deleted_at
+	`
+
+	for _, note := range notes {
+		metadata, err := encodeMetadata(note.Metadata)
+		if err != nil {
+			r.logger.Error("Failed to encode note metadata", slog.Any("note_id", note.ID), slog.Any("error", err))
+			return err
+		}
+		var deletedAt sql.NullTime
+		if note.DeletedAt != nil {
+			deletedAt = sql.NullTime{Time: note.DeletedAt.UTC(), Valid: true}
+		}
+		// The columns are timestamps without a time zone, holding UTC.
+		_, err = r.stmts.exec(ctx, r.db, query, note.ID, note.Title, note.Content, pq.Array(nonNilTags(note.Tags)),
+			metadata, note.SourcePath, note.CreatedAt.UTC(), note.UpdatedAt.UTC(), deletedAt)
+		if err != nil {
+			r.logger.Error("Failed to restore note", slog.Any("note_id", note.ID), slog.Any("error", err))
+			return fmt.Errorf("failed to restore note %d: %w", note.ID, err)
+		}
+	}
+
+	// Explicit IDs bypass the sequence, so move it past them.
+	sequence := `
+	SELECT setval(pg_get_serial_sequence('flashcards.notes', 'id'), COALESCE(MAX(id), 0) + 1, false)
+	FROM flashcards.notes
+	`
+	if _, err := r.db.ExecContext(ctx, sequence); err != nil {
+		r.logger.Error("Failed to reset note ID sequence", slog.Any("error", err))
+		return fmt.Errorf("failed to reset note ID sequence: %w", err)
+	}
+
+	r.logger.Info("Notes restored successfully", slog.Int("count", len(notes)))
+	return nil
+}
+
+// noteColumns is the select list scanNote expects.
+const noteColumns = "id, title, content, tags, metadata, COALESCE(source_path, ''), created_at, updated_at, deleted_at"
+
+func scanNote(row interface{ Scan(dest ...any) error }) (*models.Note, error) {
+	note := &models.Note{}
+	var (
+		metadata  []byte
+		deletedAt sql.NullTime
+	)
+	err := row.Scan(&note.ID, &note.Title, &note.Content, pq.Array(&note.Tags), &metadata, &note.SourcePath, &note.CreatedAt, &note.UpdatedAt, &deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	if deletedAt.Valid {
+		note.DeletedAt = &deletedAt.Time
+	}
+	if err := json.Unmarshal(metadata, &note.Metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode note metadata: %w", err)
+	}
+	return note, nil
+}
+
+// encodeMetadata stores missing metadata as an empty object rather than NULL.
+func encodeMetadata(metadata map[string]any) (string, error) {
+	if metadata == nil {
+		return "{}", nil
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode note metadata: %w", err)
+	}
+	return string(encoded), nil
+}
+
+func toInts(ids []int64) []int {
+	ints := make([]int, len(ids))
+	for i, id := range ids {
+		ints[i] = int(id)
+	}
+	return ints
+}
+
+// nonNilTags stores a missing tag list as an empty array rather than NULL.
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
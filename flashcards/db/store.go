@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+const (
+	DriverPostgres = "postgres"
+	DriverMemory   = "memory"
+)
+
+// Store bundles the instrumented repositories and unit of work for one
+// storage driver, so the server and the CLI wire storage up the same way.
+type Store struct {
+	Notes      NoteRepository
+	Todos      TodoRepository
+	UnitOfWork UnitOfWork
+	// Pool is the shared connection pool, or nil for the in-memory driver.
+	Pool *sql.DB
+
+	stmts *StmtCache
+}
+
+// OpenStore opens storage for driver. databaseURL and pool are only used by the Postgres driver.
+func OpenStore(ctx context.Context, driver, databaseURL string, pool PoolConfig, logger *slog.Logger) (*Store, error) {
+	switch driver {
+	case DriverMemory:
+		logger.Info("Using in-memory storage; data will not survive a restart")
+		memory := NewMemoryStore()
+		return &Store{
+			Notes:      NewInstrumentedNoteRepository(NewMemoryNoteRepository(memory)),
+			Todos:      NewInstrumentedTodoRepository(NewMemoryTodoRepository(memory)),
+			UnitOfWork: NewInstrumentedUnitOfWork(NewMemoryUnitOfWork(memory)),
+		}, nil
+	case DriverPostgres:
+		db, err := Open(ctx, databaseURL, pool, logger)
+		if err != nil {
+			return nil, err
+		}
+		stmts := NewStmtCache(db)
+		return &Store{
+			Notes:      NewInstrumentedNoteRepository(NewPostgresNoteRepository(db, stmts, logger)),
+			Todos:      NewInstrumentedTodoRepository(NewPostgresTodoRepository(db, stmts)),
+			UnitOfWork: NewInstrumentedUnitOfWork(NewPostgresUnitOfWork(db, stmts, logger)),
+			Pool:       db,
+			stmts:      stmts,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}
+
+// Close releases the prepared statements and the connection pool, if any.
+func (s *Store) Close() error {
+	if s.Pool == nil {
+		return nil
+	}
+	stmtErr := s.stmts.Close()
+	if err := s.Pool.Close(); err != nil {
+		return fmt.Errorf("failed to close database: %w", err)
+	}
+	return stmtErr
+}
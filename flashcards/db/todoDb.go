@@ -1,46 +1,55 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
 	"go-ai-eng-flashcards/models"
-
-	_ "github.com/lib/pq"
 )
 
 type TodoRepository interface {
-	CreateTodo(todo *models.Todo) error
-	GetTodoByID(id int) (*models.Todo, error)
-	GetAllTodos() ([]*models.Todo, error)
-	UpdateTodo(id int, updates map[string]any) error
-	DeleteTodo(id int) error
+	CreateTodo(ctx context.Context, todo *models.Todo) error
+	GetTodoByID(ctx context.Context, id int) (*models.Todo, error)
+	GetAllTodos(ctx context.Context) ([]*models.Todo, error)
+	// EachTodo calls fn with the todos GetAllTodos returns, one at a time as
+	// they are read. An error from fn stops the read and is returned.
+	EachTodo(ctx context.Context, fn func(*models.Todo) error) error
+	UpdateTodo(ctx context.Context, id int, updates map[string]any) error
+	DeleteTodo(ctx context.Context, id int) error
+	Ping(ctx context.Context) error
+
+	// RestoreTodos writes todos exactly as given, IDs and timestamps
+	// included, replacing any todo with the same ID. New todos created
+	// afterwards get IDs above the highest restored one.
+	RestoreTodos(ctx context.Context, todos []*models.Todo) error
 }
 
-type PostgresTodoRepository struct {
-	db *sql.DB
+// todoUpdates whitelists the columns UpdateTodo may set.
+var todoUpdates = updateBuilder{
+	table:       "gocourse.todos",
+	columns:     []string{"title", "description", "completed"},
+	touchColumn: "updatedAt",
+	idColumn:    "id",
 }
 
-func NewPostgresTodoRepository(databaseURL string) (*PostgresTodoRepository, error) {
-	db, err := sql.Open("postgres", databaseURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
-	}
-
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
-	}
+type PostgresTodoRepository struct {
+	db    DBTX
+	stmts *StmtCache
+}
 
-	return &PostgresTodoRepository{db: db}, nil
+// NewPostgresTodoRepository creates a todo repository on the shared pool returned by Open.
+func NewPostgresTodoRepository(db *sql.DB, stmts *StmtCache) *PostgresTodoRepository {
+	return &PostgresTodoRepository{db: db, stmts: stmts}
 }
 
-func (r *PostgresTodoRepository) CreateTodo(todo *models.Todo) error {
+func (r *PostgresTodoRepository) CreateTodo(ctx context.Context, todo *models.Todo) error {
 	query := `
 		INSERT INTO gocourse.todos (title, description, completed) 
 		VALUES ($1, $2, $3) 
 		RETURNING id, createdAt, updatedAt`
 
-	row := r.db.QueryRow(query, todo.Title, todo.Description, todo.Completed)
+	row := r.db.QueryRowContext(ctx, query, todo.Title, todo.Description, todo.Completed)
 
 	err := row.Scan(&todo.ID, &todo.CreatedAt, &todo.UpdatedAt)
 	if err != nil {
@@ -50,14 +59,14 @@ func (r *PostgresTodoRepository) CreateTodo(todo *models.Todo) error {
 	return nil
 }
 
-func (r *PostgresTodoRepository) GetTodoByID(id int) (*models.Todo, error) {
+func (r *PostgresTodoRepository) GetTodoByID(ctx context.Context, id int) (*models.Todo, error) {
 	query := `
 		SELECT id, title, description, completed, createdAt, updatedAt 
 		FROM gocourse.todos 
 		WHERE id = $1`
 
 	todo := &models.Todo{}
-	row := r.db.QueryRow(query, id)
+	row := r.db.QueryRowContext(ctx, query, id)
 
 	err := row.Scan(&todo.ID, &todo.Title, &todo.Description, &todo.Completed, &todo.CreatedAt, &todo.UpdatedAt)
 	if err != nil {
@@ -70,57 +79,55 @@ func (r *PostgresTodoRepository) GetTodoByID(id int) (*models.Todo, error) {
 	return todo, nil
 }
 
-func (r *PostgresTodoRepository) GetAllTodos() ([]*models.Todo, error) {
+func (r *PostgresTodoRepository) GetAllTodos(ctx context.Context) ([]*models.Todo, error) {
+	todos := make([]*models.Todo, 0)
+	err := r.EachTodo(ctx, func(todo *models.Todo) error {
+		todos = append(todos, todo)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+func (r *PostgresTodoRepository) EachTodo(ctx context.Context, fn func(*models.Todo) error) error {
 	query := `
 		SELECT id, title, description, completed, createdAt, updatedAt 
 		FROM gocourse.todos 
 		ORDER BY createdAt DESC`
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query todos: %w", err)
+		return fmt.Errorf("failed to query todos: %w", err)
 	}
 	defer rows.Close()
 
-	todos := make([]*models.Todo, 0)
 	for rows.Next() {
 		todo := &models.Todo{}
 		err := rows.Scan(&todo.ID, &todo.Title, &todo.Description, &todo.Completed, &todo.CreatedAt, &todo.UpdatedAt)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan todo: %w", err)
+			return fmt.Errorf("failed to scan todo: %w", err)
+		}
+		if err := fn(todo); err != nil {
+			return err
 		}
-		todos = append(todos, todo)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating over todos: %w", err)
+		return fmt.Errorf("error iterating over todos: %w", err)
 	}
 
-	return todos, nil
+	return nil
 }
 
-func (r *PostgresTodoRepository) UpdateTodo(id int, updates map[string]any) error {
-	if len(updates) == 0 {
-		return fmt.Errorf("no updates provided")
-	}
-
-	query := "UPDATE gocourse.todos SET "
-	args := []any{}
-	argIndex := 1
-
-	for field, value := range updates {
-		if argIndex > 1 {
-			query += ", "
-		}
-		query += fmt.Sprintf("%s = $%d", field, argIndex)
-		args = append(args, value)
-		argIndex++
+func (r *PostgresTodoRepository) UpdateTodo(ctx context.Context, id int, updates map[string]any) error {
+	query, args, err := todoUpdates.build(id, updates)
+	if err != nil {
+		return err
 	}
 
-	query += fmt.Sprintf(", updatedAt = NOW() WHERE id = $%d", argIndex)
-	args = append(args, id)
-
-	result, err := r.db.Exec(query, args...)
+	result, err := r.stmts.exec(ctx, r.db, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to update todo: %w", err)
 	}
@@ -137,10 +144,10 @@ func (r *PostgresTodoRepository) UpdateTodo(id int, updates map[string]any) erro
 	return nil
 }
 
-func (r *PostgresTodoRepository) DeleteTodo(id int) error {
+func (r *PostgresTodoRepository) DeleteTodo(ctx context.Context, id int) error {
 	query := "DELETE FROM gocourse.todos WHERE id = $1"
 
-	result, err := r.db.Exec(query, id)
+	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete todo: %w", err)
 	}
@@ -157,6 +164,38 @@ func (r *PostgresTodoRepository) DeleteTodo(id int) error {
 	return nil
 }
 
-func (r *PostgresTodoRepository) Close() error {
-	return r.db.Close()
+func (r *PostgresTodoRepository) RestoreTodos(ctx context.Context, todos []*models.Todo) error {
+	query := `
+		INSERT INTO gocourse.todos (id, title, description, completed, createdAt, updatedAt)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE SET
+			title = EXCLUDED.title,
+			description = EXCLUDED.description,
+			completed = EXCLUDED.completed,
+			createdAt = EXCLUDED.createdAt,
+			updatedAt = EXCLUDED.updatedAt`
+
+	for _, todo := range todos {
+		_, err := r.stmts.exec(ctx, r.db, query, todo.ID, todo.Title, todo.Description, todo.Completed, todo.CreatedAt.UTC(), todo.UpdatedAt.UTC())
+		if err != nil {
+			return fmt.Errorf("failed to restore todo %d: %w", todo.ID, err)
+		}
+	}
+
+	// Explicit IDs bypass the sequence, so move it past them.
+	sequence := `
+		SELECT setval(pg_get_serial_sequence('gocourse.todos', 'id'), COALESCE(MAX(id), 0) + 1, false)
+		FROM gocourse.todos`
+	if _, err := r.db.ExecContext(ctx, sequence); err != nil {
+		return fmt.Errorf("failed to reset todo ID sequence: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresTodoRepository) Ping(ctx context.Context) error {
+	if err := ping(ctx, r.db); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+	return nil
 }
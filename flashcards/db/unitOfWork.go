@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// DBTX is the subset of *sql.DB and *sql.Tx the Postgres repositories use, so
+// the same repository code can run on the pool or inside a transaction.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// ping checks the connection when db is the pool. A transaction already
+// holds a live connection, so there is nothing to check.
+func ping(ctx context.Context, db DBTX) error {
+	if pinger, ok := db.(interface{ PingContext(context.Context) error }); ok {
+		return pinger.PingContext(ctx)
+	}
+	return nil
+}
+
+// Repositories groups the repositories that take part in a unit of work.
+type Repositories struct {
+	Notes NoteRepository
+	Todos TodoRepository
+}
+
+// UnitOfWork runs several repository calls atomically. If fn returns an error
+// (or panics) every change made through repos is rolled back; otherwise all
+// of them are committed together. Units of work must not be nested.
+//
+// DoReadOnly runs fn on one consistent view of the data, so reads made by
+// separate calls see the same state even if other units of work commit in
+// between. It is for long reads such as exports: it does not block other
+// units of work while fn runs, and fn must not write.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error
+	DoReadOnly(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error
+}
+
+type PostgresUnitOfWork struct {
+	db     *sql.DB
+	stmts  *StmtCache
+	logger *slog.Logger
+}
+
+// NewPostgresUnitOfWork creates a unit of work that runs on a database transaction.
+func NewPostgresUnitOfWork(db *sql.DB, stmts *StmtCache, logger *slog.Logger) *PostgresUnitOfWork {
+	return &PostgresUnitOfWork{db: db, stmts: stmts, logger: logger}
+}
+
+func (u *PostgresUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error {
+	return u.do(ctx, nil, fn)
+}
+
+// DoReadOnly runs fn in a read-only REPEATABLE READ transaction, whose
+// statements all read the snapshot taken by its first one.
+func (u *PostgresUnitOfWork) DoReadOnly(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error {
+	return u.do(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, fn)
+}
+
+func (u *PostgresUnitOfWork) do(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context, repos Repositories) error) (err error) {
+	tx, err := u.db.BeginTx(ctx, opts)
+	if err != nil {
+		u.logger.Error("Failed to begin transaction", slog.Any("error", err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			u.logger.Error("Failed to roll back transaction", slog.Any("error", rbErr))
+		}
+	}()
+
+	repos := Repositories{
+		Notes: &PostgresNoteRepository{db: tx, stmts: u.stmts, logger: u.logger},
+		Todos: &PostgresTodoRepository{db: tx, stmts: u.stmts},
+	}
+	if err := fn(ctx, repos); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.logger.Error("Failed to commit transaction", slog.Any("error", err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+	return nil
+}
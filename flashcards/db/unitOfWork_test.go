@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"go-ai-eng-flashcards/models"
+)
+
+func TestPostgresDoReadOnlyUsesRepeatableRead(t *testing.T) {
+	d := &fakeDriver{release: make(chan struct{})}
+	pool := sql.OpenDB(d)
+	defer pool.Close()
+	uow := NewPostgresUnitOfWork(pool, NewStmtCache(pool), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	noop := func(context.Context, Repositories) error { return nil }
+	if err := uow.Do(context.Background(), noop); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if err := uow.DoReadOnly(context.Background(), noop); err != nil {
+		t.Fatalf("DoReadOnly: %v", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.begun) != 2 {
+		t.Fatalf("began %d transactions, want 2", len(d.begun))
+	}
+	if got := d.begun[0]; got.ReadOnly || sql.IsolationLevel(got.Isolation) != sql.LevelDefault {
+		t.Errorf("Do began %+v, want the default options", got)
+	}
+	if got := d.begun[1]; !got.ReadOnly || sql.IsolationLevel(got.Isolation) != sql.LevelRepeatableRead {
+		t.Errorf("DoReadOnly began %+v, want read-only REPEATABLE READ", got)
+	}
+}
+
+func TestMemoryDoReadOnlyDoesNotBlockWrites(t *testing.T) {
+	store := NewMemoryStore()
+	uow := NewMemoryUnitOfWork(store)
+	ctx := context.Background()
+	if err := NewMemoryNoteRepository(store).CreateNote(ctx, &models.Note{Content: "before"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := uow.DoReadOnly(ctx, func(ctx context.Context, repos Repositories) error {
+		// A unit of work started while the read is under way finishes
+		// without waiting for it.
+		written := make(chan error, 1)
+		go func() {
+			written <- uow.Do(ctx, func(ctx context.Context, repos Repositories) error {
+				return repos.Notes.CreateNote(ctx, &models.Note{Content: "during"})
+			})
+		}()
+		select {
+		case err := <-written:
+			if err != nil {
+				return err
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("write blocked behind a read-only unit of work")
+		}
+
+		// The read still sees the store as it was when it began.
+		var seen []string
+		err := repos.Notes.EachNoteIncludingDeleted(ctx, func(note *models.Note) error {
+			seen = append(seen, note.Content)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(seen) != 1 || seen[0] != "before" {
+			t.Errorf("read-only view has %q, want only the note from before", seen)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DoReadOnly: %v", err)
+	}
+
+	notes, err := NewMemoryNoteRepository(store).GetAllNotes(ctx)
+	if err != nil || len(notes) != 2 {
+		t.Fatalf("store has %d notes (%v), want 2", len(notes), err)
+	}
+}
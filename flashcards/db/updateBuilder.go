@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// updateBuilder builds parameterised UPDATE statements for one table. Only
+// whitelisted columns can be set, and they are always emitted in whitelist
+// order, so the same set of fields always produces the same SQL text.
+type updateBuilder struct {
+	table       string
+	columns     []string
+	touchColumn string
+	idColumn    string
+}
+
+// build returns the statement and arguments that set updates on the row with
+// the given id. Keys that are not whitelisted columns are rejected rather
+// than interpolated into the SQL.
+func (b updateBuilder) build(id any, updates map[string]any) (string, []any, error) {
+	if len(updates) == 0 {
+		return "", nil, fmt.Errorf("no updates provided")
+	}
+
+	for field := range updates {
+		if !b.allows(field) {
+			return "", nil, fmt.Errorf("cannot update unknown column %q on %s", field, b.table)
+		}
+	}
+
+	var query strings.Builder
+	query.WriteString("UPDATE ")
+	query.WriteString(b.table)
+	query.WriteString(" SET ")
+
+	args := make([]any, 0, len(updates)+1)
+	for _, column := range b.columns {
+		value, ok := updates[column]
+		if !ok {
+			continue
+		}
+		args = append(args, value)
+		fmt.Fprintf(&query, "%s = $%d, ", column, len(args))
+	}
+
+	args = append(args, id)
+	fmt.Fprintf(&query, "%s = NOW() WHERE %s = $%d", b.touchColumn, b.idColumn, len(args))
+
+	return query.String(), args, nil
+}
+
+func (b updateBuilder) allows(field string) bool {
+	for _, column := range b.columns {
+		if column == field {
+			return true
+		}
+	}
+	return false
+}
+
+// StmtCache prepares each distinct statement once on a pool and reuses it.
+// The builders above only ever produce a bounded set of SQL strings, so the
+// cache cannot grow without limit. One cache is shared by every repository
+// on the pool, so a statement is prepared once however many use it.
+type StmtCache struct {
+	db     *sql.DB
+	mu     sync.Mutex
+	stmts  map[string]*sql.Stmt
+	closed bool
+}
+
+// NewStmtCache creates a statement cache for db. Close it before the pool.
+func NewStmtCache(db *sql.DB) *StmtCache {
+	return &StmtCache{db: db, stmts: make(map[string]*sql.Stmt)}
+}
+
+// exec runs query as a cached prepared statement. When conn is a transaction
+// the cached statement is rebound to it so the call joins the transaction.
+func (c *StmtCache) exec(ctx context.Context, conn DBTX, query string, args ...any) (sql.Result, error) {
+	stmt, err := c.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if tx, ok := conn.(*sql.Tx); ok {
+		// Statements bound to a transaction are closed when it ends.
+		stmt = tx.StmtContext(ctx, stmt)
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+// prepare returns the cached statement for query, preparing it first if
+// needed. The lock is not held while preparing, which is a round trip to the
+// database, so one slow prepare does not hold up the others; when two
+// callers prepare the same query at once, the first one stored wins.
+func (c *StmtCache) prepare(ctx context.Context, query string) (*sql.Stmt, error) {
+	c.mu.Lock()
+	stmt, ok := c.stmts[query]
+	closed := c.closed
+	c.mu.Unlock()
+	if ok {
+		return stmt, nil
+	}
+	if closed {
+		return nil, errStmtCacheClosed
+	}
+
+	prepared, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		prepared.Close()
+		return nil, errStmtCacheClosed
+	}
+	if stmt, ok := c.stmts[query]; ok {
+		prepared.Close()
+		return stmt, nil
+	}
+	c.stmts[query] = prepared
+	return prepared, nil
+}
+
+var errStmtCacheClosed = errors.New("statement cache is closed")
+
+// Close closes every cached statement. Statements still in use by a running
+// query are closed once it finishes.
+func (c *StmtCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	var errs []error
+	for query, stmt := range c.stmts {
+		if err := stmt.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(c.stmts, query)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to close prepared statements: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeDriver prepares statements without a database. Preparing slowQuery
+// blocks until release is closed. Transactions do nothing, but the options
+// they were begun with are recorded.
+type fakeDriver struct {
+	release  chan struct{}
+	prepares atomic.Int32
+	closes   atomic.Int32
+
+	mu    sync.Mutex
+	begun []driver.TxOptions
+}
+
+const slowQuery = "SELECT slow"
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	if query == slowQuery {
+		<-c.d.release
+	}
+	c.d.prepares.Add(1)
+	return &fakeStmt{d: c.d}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) BeginTx(_ context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.begun = append(c.d.begun, opts)
+	return fakeTx{}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct{ d *fakeDriver }
+
+func (s *fakeStmt) Close() error                               { s.d.closes.Add(1); return nil }
+func (s *fakeStmt) NumInput() int                              { return -1 }
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (d *fakeDriver) OpenConnector(string) (driver.Connector, error) { return d, nil }
+func (d *fakeDriver) Connect(context.Context) (driver.Conn, error)   { return d.Open("") }
+func (d *fakeDriver) Driver() driver.Driver                          { return d }
+
+func newFakeCache(t *testing.T) (*StmtCache, *fakeDriver) {
+	t.Helper()
+	d := &fakeDriver{release: make(chan struct{})}
+	pool := sql.OpenDB(d)
+	t.Cleanup(func() { pool.Close() })
+	return NewStmtCache(pool), d
+}
+
+func TestStmtCachePrepareDoesNotBlockOtherQueries(t *testing.T) {
+	cache, d := newFakeCache(t)
+	defer close(d.release)
+	ctx := context.Background()
+
+	go cache.exec(ctx, cache.db, slowQuery)
+	time.Sleep(20 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := cache.exec(ctx, cache.db, "UPDATE notes SET title = $1 WHERE id = $2", "title", 1)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("exec() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("exec waited for an unrelated slow prepare")
+	}
+}
+
+func TestStmtCacheSharesStatementsAndCloses(t *testing.T) {
+	cache, d := newFakeCache(t)
+	close(d.release)
+	ctx := context.Background()
+	query := "UPDATE todos SET completed = $1 WHERE id = $2"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.exec(ctx, cache.db, query, true, 1); err != nil {
+				t.Errorf("exec() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(cache.stmts) != 1 {
+		t.Fatalf("cached %d statements, want 1", len(cache.stmts))
+	}
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if open := d.prepares.Load() - d.closes.Load(); open != 0 {
+		t.Fatalf("%d statements left open after Close, want 0", open)
+	}
+	if _, err := cache.exec(ctx, cache.db, query, true, 1); !errors.Is(err, errStmtCacheClosed) {
+		t.Fatalf("exec() after Close error = %v, want %v", err, errStmtCacheClosed)
+	}
+}
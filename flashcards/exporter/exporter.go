@@ -0,0 +1,266 @@
+// Package exporter writes everything stored as a single downloadable file.
+// Only the JSON format can be imported again; CSV and Markdown are for
+// spreadsheets and reading.
+package exporter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-ai-eng-flashcards/models"
+)
+
+// Format is an export file format.
+type Format string
+
+const (
+	FormatJSON     Format = "json"
+	FormatCSV      Format = "csv"
+	FormatMarkdown Format = "md"
+)
+
+// ParseFormat validates a format given by a client; empty means JSON.
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(strings.ToLower(s)); format {
+	case "":
+		return FormatJSON, nil
+	case FormatJSON, FormatCSV, FormatMarkdown:
+		return format, nil
+	case "markdown":
+		return FormatMarkdown, nil
+	}
+	return "", fmt.Errorf("format must be one of json, csv, md, got %q", s)
+}
+
+// ContentType is the media type of files in format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	}
+	return "application/json; charset=utf-8"
+}
+
+// FileName is the suggested name for an export made at t.
+func (f Format) FileName(t time.Time) string {
+	return fmt.Sprintf("flashcards-export-%s.%s", t.UTC().Format("20060102-150405"), f)
+}
+
+// Source yields the records of an export one at a time, so a large
+// database is written as it is read instead of being held in memory.
+type Source interface {
+	EachNote(fn func(*models.Note) error) error
+	EachNoteLink(fn func(models.NoteLink) error) error
+	EachTodo(fn func(*models.Todo) error) error
+}
+
+// Write writes the records of src, exported at exportedAt, to w in format.
+// Records are written as they are read, so w receives the file as it is
+// produced. Nothing reaches w before the first 4 KiB are ready or the file
+// is complete, so an early error leaves w untouched.
+func Write(w io.Writer, format Format, exportedAt time.Time, src Source) error {
+	buf := bufio.NewWriter(w)
+	var err error
+	switch format {
+	case FormatCSV:
+		err = writeCSV(buf, src)
+	case FormatMarkdown:
+		err = writeMarkdown(buf, exportedAt, src)
+	default:
+		err = writeJSON(buf, exportedAt, src)
+	}
+	if err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+// writeJSON writes a models.Export document, one record per line so large
+// exports stay readable and diffable.
+func writeJSON(w *bufio.Writer, exportedAt time.Time, src Source) error {
+	encodedAt, err := json.Marshal(exportedAt)
+	if err != nil {
+		return fmt.Errorf("failed to encode export time: %w", err)
+	}
+	fmt.Fprintf(w, "{\"version\":%d,\"exported_at\":%s,\n", models.ExportVersion, encodedAt)
+
+	if err := writeJSONArray(w, "notes", src.EachNote, ",\n"); err != nil {
+		return err
+	}
+	if err := writeJSONArray(w, "note_links", src.EachNoteLink, ",\n"); err != nil {
+		return err
+	}
+	if err := writeJSONArray(w, "todos", src.EachTodo, "}\n"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// writeJSONArray writes the records each yields as the JSON array name,
+// encoding each one as soon as it is read.
+func writeJSONArray[T any](w *bufio.Writer, name string, each func(func(T) error) error, end string) error {
+	fmt.Fprintf(w, "%q:[\n", name)
+	enc := json.NewEncoder(w)
+	first := true
+	err := each(func(item T) error {
+		if !first {
+			w.WriteByte(',')
+		}
+		first = false
+		if err := enc.Encode(item); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.WriteString("]" + end)
+	return err
+}
+
+// csvHeader lists the columns of a CSV export. Notes and todos share one
+// table, told apart by the kind column; columns that do not apply are empty.
+var csvHeader = []string{"kind", "id", "title", "content", "description", "completed", "tags", "links", "source_path", "metadata", "created_at", "updated_at", "deleted_at"}
+
+// writeCSV writes one row per note and todo. Tags and link targets are
+// joined with semicolons, and metadata is a JSON object.
+func writeCSV(w *bufio.Writer, src Source) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(csvHeader); err != nil {
+		return err
+	}
+
+	links, err := linksByNote(src)
+	if err != nil {
+		return err
+	}
+	err = src.EachNote(func(note *models.Note) error {
+		metadata, err := json.Marshal(note.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to encode metadata of note %d: %w", note.ID, err)
+		}
+		deletedAt := ""
+		if note.DeletedAt != nil {
+			deletedAt = note.DeletedAt.UTC().Format(time.RFC3339Nano)
+		}
+		var targets []string
+		for _, to := range links[note.ID] {
+			targets = append(targets, strconv.Itoa(to))
+		}
+		row := []string{
+			"note", strconv.Itoa(note.ID), note.Title, note.Content, "", "",
+			strings.Join(note.Tags, ";"), strings.Join(targets, ";"), note.SourcePath, string(metadata),
+			note.CreatedAt.UTC().Format(time.RFC3339Nano), note.UpdatedAt.UTC().Format(time.RFC3339Nano), deletedAt,
+		}
+		return out.Write(row)
+	})
+	if err != nil {
+		return err
+	}
+	err = src.EachTodo(func(todo *models.Todo) error {
+		row := []string{
+			"todo", strconv.Itoa(todo.ID), todo.Title, "", todo.Description, strconv.FormatBool(todo.Completed),
+			"", "", "", "",
+			todo.CreatedAt.UTC().Format(time.RFC3339Nano), todo.UpdatedAt.UTC().Format(time.RFC3339Nano), "",
+		}
+		return out.Write(row)
+	})
+	if err != nil {
+		return err
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// writeMarkdown writes a document with a section per note, titled by the
+// note's title or ID and marked if it is soft-deleted, followed by the todos
+// as a task list.
+func writeMarkdown(w *bufio.Writer, exportedAt time.Time, src Source) error {
+	fmt.Fprintf(w, "# Flashcards export\n\nExported %s.\n\n## Notes\n", exportedAt.UTC().Format(time.RFC1123))
+
+	links, err := linksByNote(src)
+	if err != nil {
+		return err
+	}
+	notes := 0
+	err = src.EachNote(func(note *models.Note) error {
+		notes++
+		title := note.Title
+		if title == "" {
+			title = fmt.Sprintf("Note %d", note.ID)
+		}
+		fmt.Fprintf(w, "\n### %s\n\n%s\n", title, strings.TrimSpace(note.Content))
+		if note.DeletedAt != nil {
+			fmt.Fprintf(w, "\nDeleted %s, when its source file was removed.\n", note.DeletedAt.UTC().Format(time.RFC1123))
+		}
+
+		if len(note.Tags) > 0 {
+			tags := make([]string, len(note.Tags))
+			for i, tag := range note.Tags {
+				tags[i] = "`" + tag + "`"
+			}
+			fmt.Fprintf(w, "\nTags: %s\n", strings.Join(tags, ", "))
+		}
+		if targets := links[note.ID]; len(targets) > 0 {
+			names := make([]string, len(targets))
+			for i, to := range targets {
+				names[i] = fmt.Sprintf("note %d", to)
+			}
+			fmt.Fprintf(w, "\nLinks to: %s\n", strings.Join(names, ", "))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if notes == 0 {
+		w.WriteString("\nNo notes.\n")
+	}
+
+	w.WriteString("\n## Todos\n\n")
+	todos := 0
+	err = src.EachTodo(func(todo *models.Todo) error {
+		todos++
+		box := " "
+		if todo.Completed {
+			box = "x"
+		}
+		fmt.Fprintf(w, "- [%s] %s", box, todo.Title)
+		if todo.Description != "" {
+			fmt.Fprintf(w, ": %s", strings.ReplaceAll(todo.Description, "\n", " "))
+		}
+		w.WriteString("\n")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if todos == 0 {
+		w.WriteString("No todos.\n")
+	}
+	return nil
+}
+
+// linksByNote reads every note link up front, since CSV and Markdown show a
+// note's links with the note. Links are pairs of IDs, so this stays small
+// even when the notes themselves would not.
+func linksByNote(src Source) (map[int][]int, error) {
+	byNote := make(map[int][]int)
+	err := src.EachNoteLink(func(link models.NoteLink) error {
+		byNote[link.From] = append(byNote[link.From], link.To)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return byNote, nil
+}
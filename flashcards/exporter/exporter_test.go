@@ -0,0 +1,142 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-ai-eng-flashcards/models"
+)
+
+// sliceSource yields an Export's records, failing with err once it has
+// yielded failAfter notes when err is set.
+type sliceSource struct {
+	export    *models.Export
+	err       error
+	failAfter int
+}
+
+func (s sliceSource) EachNote(fn func(*models.Note) error) error {
+	for i, note := range s.export.Notes {
+		if s.err != nil && i == s.failAfter {
+			return s.err
+		}
+		if err := fn(note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s sliceSource) EachNoteLink(fn func(models.NoteLink) error) error {
+	for _, link := range s.export.NoteLinks {
+		if err := fn(link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s sliceSource) EachTodo(fn func(*models.Todo) error) error {
+	for _, todo := range s.export.Todos {
+		if err := fn(todo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func testExport() *models.Export {
+	created := time.Date(2026, time.October, 1, 9, 30, 0, 0, time.UTC)
+	return &models.Export{
+		Version:    models.ExportVersion,
+		ExportedAt: time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC),
+		Notes: []*models.Note{
+			{ID: 1, Title: "Ragnarök", Content: "The twilight of the gods.", Tags: []string{"norse"}, Metadata: map[string]any{}, CreatedAt: created, UpdatedAt: created},
+			{ID: 2, Title: "", Content: "Yggdrasil links the nine worlds.", Tags: []string{}, Metadata: map[string]any{"source": "edda"}, CreatedAt: created, UpdatedAt: created},
+		},
+		NoteLinks: []models.NoteLink{{From: 2, To: 1}},
+		Todos:     []*models.Todo{{ID: 7, Title: "Read Völuspá", Completed: true, CreatedAt: created, UpdatedAt: created}},
+	}
+}
+
+func TestWriteJSONRoundTrip(t *testing.T) {
+	want := testExport()
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, want.ExportedAt, sliceSource{export: want}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	var got models.Export
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("export is not valid JSON: %v\n%s", err, buf.String())
+	}
+	wantJSON, _ := json.Marshal(want)
+	gotJSON, _ := json.Marshal(&got)
+	if !bytes.Equal(gotJSON, wantJSON) {
+		t.Fatalf("round trip = %s, want %s", gotJSON, wantJSON)
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	empty := &models.Export{ExportedAt: time.Now().UTC()}
+	for _, format := range []Format{FormatJSON, FormatCSV, FormatMarkdown} {
+		var buf bytes.Buffer
+		if err := Write(&buf, format, empty.ExportedAt, sliceSource{export: empty}); err != nil {
+			t.Fatalf("Write(%s) error = %v", format, err)
+		}
+		if format == FormatJSON {
+			var got models.Export
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil || len(got.Notes) != 0 || len(got.Todos) != 0 {
+				t.Fatalf("empty JSON export = %s, error %v", buf.String(), err)
+			}
+		}
+	}
+}
+
+func TestWriteCSVAndMarkdown(t *testing.T) {
+	export := testExport()
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatCSV, export.ExportedAt, sliceSource{export: export}); err != nil {
+		t.Fatalf("Write(csv) error = %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("export is not valid CSV: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("CSV has %d rows, want a header and 3 records", len(rows))
+	}
+	if rows[2][0] != "note" || rows[2][7] != "1" {
+		t.Fatalf("CSV note row = %q, want note 2 linking to note 1", rows[2])
+	}
+
+	buf.Reset()
+	if err := Write(&buf, FormatMarkdown, export.ExportedAt, sliceSource{export: export}); err != nil {
+		t.Fatalf("Write(md) error = %v", err)
+	}
+	for _, want := range []string{"### Ragnarök", "### Note 2", "Links to: note 1", "- [x] Read Völuspá"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("Markdown export is missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestWriteStopsOnSourceError(t *testing.T) {
+	failure := errors.New("connection reset")
+	var buf bytes.Buffer
+	err := Write(&buf, FormatJSON, time.Now(), sliceSource{export: testExport(), err: failure, failAfter: 1})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Write() error = %v, want %v", err, failure)
+	}
+	// A small export is still buffered when the read fails, so nothing
+	// reached the writer and the handler can send an error status instead.
+	if buf.Len() != 0 {
+		t.Fatalf("Write() wrote %d bytes before failing, want 0", buf.Len())
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go-ai-eng-flashcards/exporter"
+	"go-ai-eng-flashcards/models"
+	"go-ai-eng-flashcards/services"
+
+	"github.com/gorilla/mux"
+)
+
+// MaxRestoreBytes caps the size of an export sent back for import. It is
+// larger than MaxImportBytes because it holds a whole database.
+const MaxRestoreBytes = 128 << 20
+
+// ExportHandler downloads everything stored and restores JSON exports.
+type ExportHandler struct {
+	service *services.ExportService
+	logger  *slog.Logger
+}
+
+// NewExportHandler creates a new instance of ExportHandler.
+func NewExportHandler(service *services.ExportService, logger *slog.Logger) *ExportHandler {
+	return &ExportHandler{service: service, logger: logger}
+}
+
+func (h *ExportHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/export", h.Export).Methods("GET")
+	router.HandleFunc("/import", h.Import).Methods("POST")
+}
+
+// Export downloads every note, note link and todo as JSON (the default),
+// CSV or Markdown, chosen with the format query parameter.
+func (h *ExportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Received request to export data", slog.String("format", r.URL.Query().Get("format")))
+
+	format, err := exporter.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		h.logger.Error("Invalid export format", slog.Any("error", err))
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	exportedAt := time.Now().UTC()
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="`+format.FileName(exportedAt)+`"`)
+
+	out := &startedWriter{w: w}
+	if err := h.service.WriteExport(r.Context(), out, format, exportedAt); err != nil {
+		h.logger.Error("Failed to export data", slog.Any("error", err))
+		// Once the file has started, the status is sent and a failure can
+		// only be logged; the client sees a truncated file.
+		if !out.started {
+			w.Header().Del("Content-Disposition")
+			h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to export data")
+		}
+		return
+	}
+
+	h.logger.Info("Data exported successfully", slog.String("format", string(format)))
+}
+
+// startedWriter records whether anything has been written to w, after
+// which the response status can no longer be changed.
+type startedWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (s *startedWriter) Write(p []byte) (int, error) {
+	s.started = true
+	return s.w.Write(p)
+}
+
+// Import restores a JSON export, keeping IDs and timestamps. Notes and
+// todos with the same ID as stored ones replace them.
+func (h *ExportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Received request to import an export")
+	r.Body = http.MaxBytesReader(w, r.Body, MaxRestoreBytes)
+
+	var export models.Export
+	if err := json.NewDecoder(r.Body).Decode(&export); err != nil {
+		h.logger.Error("Invalid export document", slog.Any("error", err))
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		h.writeErrorResponse(w, status, "Invalid JSON payload")
+		return
+	}
+
+	result, err := h.service.Restore(r.Context(), &export)
+	if err != nil {
+		h.logger.Error("Failed to import export", slog.Any("error", err))
+		if errors.Is(err, services.ErrInvalidExport) {
+			h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		} else {
+			h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to import; nothing was imported")
+		}
+		return
+	}
+
+	h.logger.Info("Export imported successfully", slog.Int("notes", result.Notes), slog.Int("todos", result.Todos))
+	h.writeJSONResponse(w, http.StatusOK, result)
+}
+
+func (h *ExportHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, data any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("Failed to write JSON response", slog.Any("error", err))
+	}
+}
+
+func (h *ExportHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": message}); err != nil {
+		h.logger.Error("Failed to write error response", slog.Any("error", err))
+	}
+}
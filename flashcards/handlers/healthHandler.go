@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+	// statusHealthy is what /health has always answered with.
+	statusHealthy = "healthy"
+
+	// defaultCheckTimeout bounds how long a single dependency check may take.
+	defaultCheckTimeout = 2 * time.Second
+)
+
+// DependencyCheck is a named readiness check against an external dependency.
+type DependencyCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+	// CacheFor reuses the last result for this long, for checks that are
+	// too expensive to run on every probe (e.g. a paid LLM call).
+	CacheFor time.Duration
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	lastErr   error
+	// running is closed when the check in flight finishes; nil when none is.
+	running chan struct{}
+}
+
+type checkResult struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	Cached    bool   `json:"cached,omitempty"`
+}
+
+type healthResponse struct {
+	Status        string                 `json:"status"`
+	Version       string                 `json:"version"`
+	Uptime        string                 `json:"uptime"`
+	UptimeSeconds int64                  `json:"uptime_seconds"`
+	Checks        map[string]checkResult `json:"checks,omitempty"`
+}
+
+type poolStatsResponse struct {
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDuration       string `json:"wait_duration"`
+	MaxIdleClosed      int64  `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64  `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
+}
+
+// HealthHandler serves liveness and readiness probes.
+type HealthHandler struct {
+	checks    []*DependencyCheck
+	poolStats func() sql.DBStats
+	version   string
+	startedAt time.Time
+	logger    *slog.Logger
+}
+
+// NewHealthHandler creates a HealthHandler that reports readiness based on checks.
+func NewHealthHandler(version string, logger *slog.Logger, checks ...*DependencyCheck) *HealthHandler {
+	return &HealthHandler{checks: checks, version: version, startedAt: time.Now(), logger: logger}
+}
+
+// SetPoolStats enables /debug/db, which reports the shared connection
+// pool's statistics. It is only called when DEBUG_ENDPOINTS is on, since the
+// route has no authentication.
+func (h *HealthHandler) SetPoolStats(stats func() sql.DBStats) {
+	h.poolStats = stats
+}
+
+func (h *HealthHandler) RegisterRoutes(router *mux.Router) {
+	if h.poolStats != nil {
+		router.HandleFunc("/debug/db", h.PoolStats).Methods("GET")
+	}
+	router.HandleFunc("/livez", h.Livez).Methods("GET")
+	router.HandleFunc("/readyz", h.Readyz).Methods("GET")
+	// /health predates the split probes and stays a liveness check, so
+	// deployments probing it are not restarted while the database is slow.
+	router.HandleFunc("/health", h.Health).Methods("GET")
+}
+
+// Livez reports that the process is up and serving requests. It never checks
+// dependencies, so a database outage does not get the process restarted.
+func (h *HealthHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	h.writeJSONResponse(w, http.StatusOK, h.baseResponse(statusOK))
+}
+
+// Health is Livez with the status /health has always reported.
+func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
+	h.writeJSONResponse(w, http.StatusOK, h.baseResponse(statusHealthy))
+}
+
+// Readyz runs every dependency check concurrently and reports 503 if any fails.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	results := make(map[string]checkResult, len(h.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check *DependencyCheck) {
+			defer wg.Done()
+			result := check.run(r.Context())
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	res := h.baseResponse(statusOK)
+	res.Checks = results
+	statusCode := http.StatusOK
+	for name, result := range results {
+		if result.Status != statusOK {
+			h.logger.Error("Readiness check failed", slog.String("dependency", name), slog.String("error", result.Error))
+			res.Status = statusUnavailable
+			statusCode = http.StatusServiceUnavailable
+		}
+	}
+
+	h.writeJSONResponse(w, statusCode, res)
+}
+
+// PoolStats reports the shared database connection pool statistics for diagnostics.
+func (h *HealthHandler) PoolStats(w http.ResponseWriter, r *http.Request) {
+	stats := h.poolStats()
+	h.writeJSONResponse(w, http.StatusOK, poolStatsResponse{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration.String(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	})
+}
+
+func (h *HealthHandler) baseResponse(status string) healthResponse {
+	uptime := time.Since(h.startedAt)
+	return healthResponse{
+		Status:        status,
+		Version:       h.version,
+		Uptime:        uptime.Round(time.Second).String(),
+		UptimeSeconds: int64(uptime.Seconds()),
+	}
+}
+
+// run returns the check's result. The lock is only held to read and record
+// results: while a check is in flight, other probes get the previous result,
+// or wait for the running check if there is none yet, rather than queueing
+// behind a dependency that hangs.
+func (c *DependencyCheck) run(ctx context.Context) checkResult {
+	c.mu.Lock()
+	if c.CacheFor > 0 && !c.checkedAt.IsZero() && time.Since(c.checkedAt) < c.CacheFor {
+		err := c.lastErr
+		c.mu.Unlock()
+		return newCheckResult(err, 0, true)
+	}
+	if running := c.running; running != nil {
+		if !c.checkedAt.IsZero() {
+			err := c.lastErr
+			c.mu.Unlock()
+			return newCheckResult(err, 0, true)
+		}
+		c.mu.Unlock()
+		select {
+		case <-running:
+		case <-ctx.Done():
+			return newCheckResult(ctx.Err(), 0, false)
+		}
+		c.mu.Lock()
+		err := c.lastErr
+		c.mu.Unlock()
+		return newCheckResult(err, 0, true)
+	}
+	running := make(chan struct{})
+	c.running = running
+	c.mu.Unlock()
+
+	// Other probes share this check's result, so it must not be cut short
+	// when the probe that started it goes away.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.Check(ctx)
+	latency := time.Since(start)
+
+	c.mu.Lock()
+	c.checkedAt = time.Now()
+	c.lastErr = err
+	c.running = nil
+	c.mu.Unlock()
+	close(running)
+
+	return newCheckResult(err, latency, false)
+}
+
+func newCheckResult(err error, latency time.Duration, cached bool) checkResult {
+	result := checkResult{Status: statusOK, LatencyMs: latency.Milliseconds(), Cached: cached}
+	if err != nil {
+		result.Status = statusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (h *HealthHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, data any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("Failed to write JSON response", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestHealthIsLiveness(t *testing.T) {
+	down := &DependencyCheck{Name: "db", Check: func(ctx context.Context) error { return errors.New("down") }}
+	router := mux.NewRouter()
+	NewHealthHandler("test", slog.New(slog.NewTextHandler(io.Discard, nil)), down).RegisterRoutes(router)
+
+	for path, want := range map[string]int{
+		"/health":   http.StatusOK,
+		"/livez":    http.StatusOK,
+		"/readyz":   http.StatusServiceUnavailable,
+		"/debug/db": http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("GET %s = %d, want %d", path, rec.Code, want)
+		}
+	}
+}
+
+func TestDependencyCheckDoesNotQueueBehindHungCheck(t *testing.T) {
+	release := make(chan struct{})
+	calls := 0
+	check := &DependencyCheck{Name: "db", Check: func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			return nil
+		}
+		<-release
+		return nil
+	}}
+	defer close(release)
+
+	if result := check.run(context.Background()); result.Status != statusOK {
+		t.Fatalf("first run status = %q, want ok", result.Status)
+	}
+
+	go check.run(context.Background())
+	time.Sleep(20 * time.Millisecond)
+
+	done := make(chan checkResult, 1)
+	go func() { done <- check.run(context.Background()) }()
+	select {
+	case result := <-done:
+		if result.Status != statusOK || !result.Cached {
+			t.Fatalf("result = %+v, want the previous ok result", result)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("probe waited for the hung check instead of using the previous result")
+	}
+}
@@ -0,0 +1,483 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"go-ai-eng-flashcards/importer"
+	"go-ai-eng-flashcards/models"
+	"go-ai-eng-flashcards/services"
+
+	"github.com/gorilla/mux"
+)
+
+// MaxImportBytes caps the size of an import request body.
+const MaxImportBytes = 32 << 20
+
+// ImportHandler handles bulk imports of notes from uploaded files, and
+// exports to formats that other tools import.
+type ImportHandler struct {
+	service *services.NoteService
+	logger  *slog.Logger
+}
+
+// NewImportHandler creates a new instance of ImportHandler.
+func NewImportHandler(service *services.NoteService, logger *slog.Logger) *ImportHandler {
+	return &ImportHandler{service: service, logger: logger}
+}
+
+func (h *ImportHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/notes/import", h.ImportMarkdown).Methods("POST")
+	router.HandleFunc("/notes/import/obsidian", h.ImportObsidian).Methods("POST")
+	router.HandleFunc("/notes/import/anki", h.ImportAnki).Methods("POST")
+	router.HandleFunc("/notes/import/csv", h.ImportCards).Methods("POST")
+	router.HandleFunc("/notes/export/anki", h.ExportAnki).Methods("GET")
+}
+
+// ImportMarkdown creates notes from Markdown files. The body is either a
+// multipart form with one or more .md or .zip file parts, a zip archive
+// (Content-Type application/zip) or a single Markdown document
+// (Content-Type text/markdown). Files that cannot be used are reported as
+// failed items alongside the notes that were created.
+func (h *ImportHandler) ImportMarkdown(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Received request to import Markdown notes")
+
+	opts, err := markdownOptions(r)
+	if err != nil {
+		h.logger.Error("Invalid import options", slog.Any("error", err))
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	files, err := h.readUpload(w, r)
+	if err != nil {
+		h.logger.Error("Failed to read import upload", slog.Any("error", err))
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) || errors.Is(err, importer.ErrUploadTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		h.writeErrorResponse(w, status, err.Error())
+		return
+	}
+	if len(files) == 0 {
+		h.writeErrorResponse(w, http.StatusBadRequest, "no files to import")
+		return
+	}
+
+	notes, skipped := importer.SplitMarkdownFiles(files, opts)
+
+	result, err := h.service.ImportNotes(r.Context(), notes)
+	if err != nil {
+		h.logger.Error("Failed to import notes", slog.Any("error", err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to import notes; nothing was imported")
+		return
+	}
+	for _, file := range skipped {
+		result.Add(models.ImportItemResult{Source: file.Name, Status: models.ImportFailed, Error: "not a Markdown file"})
+	}
+
+	h.logger.Info("Markdown import completed", slog.Int("created", result.Created), slog.Int("failed", result.Failed))
+	h.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// ImportObsidian imports an Obsidian vault, uploaded as a zip archive either
+// as the body or as a multipart file part. Each Markdown file becomes one
+// note, and notes imported from the same vault before are updated in place.
+// The optional vault query parameter names the vault when the archive does
+// not hold a single top-level folder.
+func (h *ImportHandler) ImportObsidian(w http.ResponseWriter, r *http.Request) {
+	vaultName := r.URL.Query().Get("vault")
+	h.logger.Info("Received request to import an Obsidian vault", slog.String("vault", vaultName))
+
+	files, err := h.readUpload(w, r)
+	if err != nil {
+		h.logger.Error("Failed to read vault upload", slog.Any("error", err))
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) || errors.Is(err, importer.ErrUploadTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		h.writeErrorResponse(w, status, err.Error())
+		return
+	}
+	if len(files) == 0 {
+		h.writeErrorResponse(w, http.StatusBadRequest, "no files to import")
+		return
+	}
+
+	vault, err := importer.ParseObsidianVault(files, vaultName)
+	if err != nil {
+		h.logger.Error("Failed to parse Obsidian vault", slog.Any("error", err))
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.service.ImportNotes(r.Context(), vault.Notes)
+	if err != nil {
+		h.logger.Error("Failed to import vault notes", slog.Any("error", err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to import notes; nothing was imported")
+		return
+	}
+	for _, file := range vault.Skipped {
+		result.Add(models.ImportItemResult{Source: file.Name, Status: models.ImportFailed, Error: "not a Markdown file"})
+	}
+
+	h.logger.Info("Obsidian import completed", slog.String("vault", vault.Name), slog.Int("created", result.Created), slog.Int("updated", result.Updated), slog.Int("failed", result.Failed))
+	h.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// ImportAnki imports Anki packages (.apkg), uploaded as the body or as
+// multipart file parts. Each Anki note becomes one note, and notes imported
+// from Anki before are matched by GUID and updated in place.
+func (h *ImportHandler) ImportAnki(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Received request to import Anki packages")
+
+	files, err := h.readPackages(w, r)
+	if err != nil {
+		h.logger.Error("Failed to read Anki upload", slog.Any("error", err))
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) || errors.Is(err, importer.ErrUploadTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		h.writeErrorResponse(w, status, err.Error())
+		return
+	}
+	if len(files) == 0 {
+		h.writeErrorResponse(w, http.StatusBadRequest, "no files to import")
+		return
+	}
+
+	var notes []importer.Note
+	for _, file := range files {
+		parsed, err := importer.ParseAnkiPackage(file)
+		if err != nil {
+			h.logger.Error("Failed to read Anki package", slog.String("file", file.Name), slog.Any("error", err))
+			h.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("%s: %v", file.Name, err))
+			return
+		}
+		notes = append(notes, parsed...)
+	}
+
+	result, err := h.service.ImportNotes(r.Context(), notes)
+	if err != nil {
+		h.logger.Error("Failed to import Anki notes", slog.Any("error", err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to import notes; nothing was imported")
+		return
+	}
+
+	h.logger.Info("Anki import completed", slog.Int("created", result.Created), slog.Int("updated", result.Updated), slog.Int("failed", result.Failed))
+	h.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// ImportCards creates a note from each row of term/definition files, such as
+// Quizlet exports (tab-separated by default) or CSV spreadsheets, uploaded as
+// the body or as multipart file parts. The delimiter, header and encoding
+// query parameters override detection. With dry_run=true nothing is stored
+// and the response lists the notes that would be created.
+func (h *ImportHandler) ImportCards(w http.ResponseWriter, r *http.Request) {
+	var dryRun bool
+	h.logger.Info("Received request to import card files")
+
+	opts, err := cardOptions(r)
+	if err == nil {
+		dryRun, err = parseDryRun(r)
+	}
+	if err != nil {
+		h.logger.Error("Invalid import options", slog.Any("error", err))
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	files, err := h.readCardFiles(w, r)
+	if err != nil {
+		h.logger.Error("Failed to read card upload", slog.Any("error", err))
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) || errors.Is(err, importer.ErrUploadTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		h.writeErrorResponse(w, status, err.Error())
+		return
+	}
+	if len(files) == 0 {
+		h.writeErrorResponse(w, http.StatusBadRequest, "no files to import")
+		return
+	}
+
+	var (
+		notes   []importer.Note
+		formats []models.CardFileFormat
+		skipped []importer.File
+	)
+	for _, file := range files {
+		if !importer.IsCardFile(file.Name) {
+			skipped = append(skipped, file)
+			continue
+		}
+		parsed, err := importer.ParseCards(file, opts)
+		if err != nil {
+			h.logger.Error("Failed to read card file", slog.String("file", file.Name), slog.Any("error", err))
+			h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		notes = append(notes, parsed.Notes...)
+		formats = append(formats, models.CardFileFormat{
+			Source:    file.Name,
+			Delimiter: importer.DelimiterName(parsed.Delimiter),
+			Encoding:  parsed.Encoding,
+			Header:    parsed.Header,
+			Rows:      parsed.Rows,
+		})
+	}
+
+	var result *models.ImportResult
+	if dryRun {
+		result, err = h.service.PreviewImport(r.Context(), notes)
+	} else {
+		result, err = h.service.ImportNotes(r.Context(), notes)
+	}
+	if err != nil {
+		h.logger.Error("Failed to import cards", slog.Any("error", err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to import notes; nothing was imported")
+		return
+	}
+	for _, file := range skipped {
+		result.Add(models.ImportItemResult{Source: file.Name, Status: models.ImportFailed, Error: "not a CSV, TSV or text file"})
+	}
+
+	h.logger.Info("Card import completed", slog.Bool("dry_run", dryRun), slog.Int("created", result.Created), slog.Int("updated", result.Updated), slog.Int("failed", result.Failed))
+	h.writeJSONResponse(w, http.StatusOK, models.CardImportResult{ImportResult: *result, Files: formats})
+}
+
+// ExportAnki downloads every note as an Anki package. The optional deck
+// query parameter names the deck for notes that did not come from Anki.
+func (h *ImportHandler) ExportAnki(w http.ResponseWriter, r *http.Request) {
+	deck := r.URL.Query().Get("deck")
+	h.logger.Info("Received request to export notes to Anki", slog.String("deck", deck))
+
+	data, err := h.service.ExportAnki(r.Context(), deck)
+	if err != nil {
+		h.logger.Error("Failed to export notes to Anki", slog.Any("error", err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to export notes")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/apkg")
+	w.Header().Set("Content-Disposition", `attachment; filename="flashcards.apkg"`)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		h.logger.Error("Failed to write Anki package", slog.Any("error", err))
+		return
+	}
+	h.logger.Info("Anki export completed", slog.Int("bytes", len(data)))
+}
+
+func markdownOptions(r *http.Request) (importer.MarkdownOptions, error) {
+	opts := importer.DefaultMarkdownOptions
+	query := r.URL.Query()
+
+	mode, err := importer.ParseSplitMode(query.Get("split"))
+	if err != nil {
+		return opts, err
+	}
+	opts.Split = mode
+
+	if level := query.Get("heading_level"); level != "" {
+		n, err := strconv.Atoi(level)
+		if err != nil || n < 1 || n > 6 {
+			return opts, fmt.Errorf("heading_level must be between 1 and 6, got %q", level)
+		}
+		opts.HeadingLevel = n
+	}
+	return opts, nil
+}
+
+// cardOptions reads the delimiter, header and encoding query parameters. The
+// encoding falls back to the charset of a text body's Content-Type.
+func cardOptions(r *http.Request) (importer.CardOptions, error) {
+	var opts importer.CardOptions
+	query := r.URL.Query()
+
+	delimiter, err := importer.ParseDelimiter(query.Get("delimiter"))
+	if err != nil {
+		return opts, err
+	}
+	opts.Delimiter = delimiter
+
+	if opts.Header, err = importer.ParseHeaderMode(query.Get("header")); err != nil {
+		return opts, err
+	}
+
+	name := query.Get("encoding")
+	if name == "" {
+		if mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil && mediaType != "multipart/form-data" {
+			name = params["charset"]
+		}
+	}
+	if opts.Encoding, err = importer.ParseEncoding(name); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+func parseDryRun(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("dry_run")
+	if value == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("dry_run must be true or false, got %q", value)
+	}
+	return dryRun, nil
+}
+
+// readUpload collects the uploaded files, expanding zip archives.
+func (h *ImportHandler) readUpload(w http.ResponseWriter, r *http.Request) ([]importer.File, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImportBytes)
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("missing or invalid Content-Type")
+	}
+
+	switch mediaType {
+	case "multipart/form-data":
+		return readMultipart(r)
+	case "application/zip", "application/x-zip-compressed":
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		return importer.ExpandZip(data, importer.NewBudget())
+	case "text/markdown", "text/x-markdown", "text/plain":
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		name := r.URL.Query().Get("filename")
+		if name == "" {
+			name = "upload.md"
+		}
+		return []importer.File{{Name: name, Data: data}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported Content-Type %q; use multipart/form-data, application/zip or text/markdown", mediaType)
+	}
+}
+
+// readPackages collects uploaded files without looking inside them, since
+// Anki packages are zip archives that must stay whole.
+func (h *ImportHandler) readPackages(w http.ResponseWriter, r *http.Request) ([]importer.File, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImportBytes)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		return readMultipart(r)
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	name := r.URL.Query().Get("filename")
+	if name == "" {
+		name = "upload.apkg"
+	}
+	return []importer.File{{Name: name, Data: data}}, nil
+}
+
+// readCardFiles collects the uploaded card files, expanding zip archives. A
+// body that is not multipart is one file, named by the filename query
+// parameter.
+func (h *ImportHandler) readCardFiles(w http.ResponseWriter, r *http.Request) ([]importer.File, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImportBytes)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "multipart/form-data":
+		return readMultipart(r)
+	case "application/zip", "application/x-zip-compressed":
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		return importer.ExpandZip(data, importer.NewBudget())
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	name := r.URL.Query().Get("filename")
+	if name == "" {
+		name = "upload.txt"
+	}
+	return []importer.File{{Name: name, Data: data}}, nil
+}
+
+func readMultipart(r *http.Request) ([]importer.File, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("invalid multipart body: %w", err)
+	}
+
+	budget := importer.NewBudget()
+	var files []importer.File
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid multipart body: %w", err)
+		}
+		if part.FileName() == "" {
+			continue
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		if importer.IsZip(part.FileName()) {
+			expanded, err := importer.ExpandZip(data, budget)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", part.FileName(), err)
+			}
+			files = append(files, expanded...)
+		} else {
+			if err := budget.Take(len(data)); err != nil {
+				return nil, err
+			}
+			files = append(files, importer.File{Name: part.FileName(), Data: data})
+		}
+		if len(files) > importer.MaxFiles {
+			return nil, fmt.Errorf("upload contains more than %d files", importer.MaxFiles)
+		}
+	}
+}
+
+func (h *ImportHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, data any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("Failed to write JSON response", slog.Any("error", err))
+	}
+}
+
+func (h *ImportHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": message}); err != nil {
+		h.logger.Error("Failed to write error response", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-ai-eng-flashcards/ingest"
+	"go-ai-eng-flashcards/services"
+
+	"github.com/gorilla/mux"
+)
+
+// IngestHandler turns uploaded PDF and HTML documents into notes.
+type IngestHandler struct {
+	service *services.IngestService
+	logger  *slog.Logger
+}
+
+// NewIngestHandler creates a new instance of IngestHandler.
+func NewIngestHandler(service *services.IngestService, logger *slog.Logger) *IngestHandler {
+	return &IngestHandler{service: service, logger: logger}
+}
+
+func (h *IngestHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/notes/ingest", h.Ingest).Methods("POST")
+}
+
+// upload is a document read from a request.
+type upload struct {
+	name        string
+	contentType string
+	data        []byte
+}
+
+// Ingest creates notes from a PDF or HTML document, uploaded as the body or
+// as a multipart file part. The text is cut into passages of up to
+// chunk_size characters, one note each; with condense=true the LLM rewrites
+// each passage as a study note. The optional tags parameter is a
+// comma-separated list of tags for every note.
+func (h *IngestHandler) Ingest(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Received request to ingest a document")
+
+	opts, err := ingestOptions(r)
+	if err != nil {
+		h.logger.Error("Invalid ingest options", slog.Any("error", err))
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	doc, err := h.readDocument(w, r)
+	if err != nil {
+		h.logger.Error("Failed to read document upload", slog.Any("error", err))
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		h.writeErrorResponse(w, status, err.Error())
+		return
+	}
+
+	kind, err := ingest.DetectKind(doc.name, doc.contentType, doc.data)
+	if err != nil {
+		h.logger.Error("Unsupported document", slog.String("name", doc.name), slog.Any("error", err))
+		h.writeErrorResponse(w, http.StatusUnsupportedMediaType, err.Error())
+		return
+	}
+	extracted, err := ingest.Extract(kind, doc.contentType, doc.data)
+	if err != nil {
+		h.logger.Error("Failed to extract document text", slog.String("name", doc.name), slog.Any("error", err))
+		h.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("%s: %v", doc.name, err))
+		return
+	}
+	if len(extracted.Sections) == 0 {
+		h.writeErrorResponse(w, http.StatusUnprocessableEntity, fmt.Sprintf("%s has no text; scanned PDFs are not supported", doc.name))
+		return
+	}
+
+	result, err := h.service.IngestDocument(r.Context(), doc.name, extracted, opts)
+	if err != nil {
+		h.logger.Error("Failed to ingest document", slog.String("name", doc.name), slog.Any("error", err))
+		if errors.Is(err, services.ErrTooManyChunks) {
+			h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		} else {
+			h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to ingest document; nothing was imported")
+		}
+		return
+	}
+
+	h.logger.Info("Document ingested", slog.String("name", doc.name), slog.Int("chunks", result.Document.Chunks), slog.Int("created", result.Created), slog.Int("failed", result.Failed))
+	h.writeJSONResponse(w, http.StatusOK, result)
+}
+
+func ingestOptions(r *http.Request) (services.IngestOptions, error) {
+	var opts services.IngestOptions
+	query := r.URL.Query()
+
+	if value := query.Get("condense"); value != "" {
+		condense, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("condense must be true or false, got %q", value)
+		}
+		opts.Condense = condense
+	}
+
+	if value := query.Get("chunk_size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < ingest.MinChunkSize || size > ingest.MaxChunkSize {
+			return opts, fmt.Errorf("chunk_size must be between %d and %d, got %q", ingest.MinChunkSize, ingest.MaxChunkSize, value)
+		}
+		opts.ChunkSize = size
+	}
+
+	for _, tag := range strings.Split(query.Get("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			opts.Tags = append(opts.Tags, tag)
+		}
+	}
+	return opts, nil
+}
+
+// readDocument reads the one document of an upload: the first file part of
+// a multipart form, or else the body, named by the filename query parameter.
+func (h *IngestHandler) readDocument(w http.ResponseWriter, r *http.Request) (*upload, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImportBytes)
+
+	contentType := r.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "multipart/form-data" {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) == 0 {
+			return nil, fmt.Errorf("no document to ingest")
+		}
+		name := r.URL.Query().Get("filename")
+		if name == "" {
+			name = "upload"
+		}
+		return &upload{name: name, contentType: contentType, data: data}, nil
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("invalid multipart body: %w", err)
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("no document to ingest")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid multipart body: %w", err)
+		}
+		if part.FileName() == "" {
+			continue
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		return &upload{name: part.FileName(), contentType: part.Header.Get("Content-Type"), data: data}, nil
+	}
+}
+
+func (h *IngestHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, data any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("Failed to write JSON response", slog.Any("error", err))
+	}
+}
+
+func (h *IngestHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": message}); err != nil {
+		h.logger.Error("Failed to write error response", slog.Any("error", err))
+	}
+}
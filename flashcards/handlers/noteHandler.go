@@ -29,6 +29,8 @@ func (h *NoteHandler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/notes/{id:[0-9]+}", h.GetNoteByID).Methods("GET")
 	router.HandleFunc("/notes/{id:[0-9]+}", h.UpdateNote).Methods("PUT")
 	router.HandleFunc("/notes/{id:[0-9]+}", h.DeleteNote).Methods("DELETE")
+	router.HandleFunc("/notes/{id:[0-9]+}/links", h.GetNoteLinks).Methods("GET")
+	router.HandleFunc("/notes/{id:[0-9]+}/chunks", h.GetNoteChunks).Methods("GET")
 }
 
 func (h *NoteHandler) CreateNote(w http.ResponseWriter, r *http.Request) {
@@ -40,7 +42,7 @@ func (h *NoteHandler) CreateNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	note, err := h.service.CreateNote(&req)
+	note, err := h.service.CreateNote(r.Context(), &req)
 	if err != nil {
 		h.logger.Error("Failed to create note", slog.Any("error", err))
 		h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
@@ -53,7 +55,7 @@ func (h *NoteHandler) CreateNote(w http.ResponseWriter, r *http.Request) {
 
 func (h *NoteHandler) GetAllNotes(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info("Received request to get all notes")
-	notes, err := h.service.GetAllNotes()
+	notes, err := h.service.GetAllNotes(r.Context())
 	if err != nil {
 		h.logger.Error("Failed to retrieve all notes", slog.Any("error", err))
 		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve notes")
@@ -75,7 +77,7 @@ func (h *NoteHandler) GetNoteByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	note, err := h.service.GetNoteByID(id)
+	note, err := h.service.GetNoteByID(r.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to retrieve note by ID", slog.Any("note_id", id), slog.Any("error", err))
 		if noteErrorContainsNotFound(err.Error()) {
@@ -90,6 +92,59 @@ func (h *NoteHandler) GetNoteByID(w http.ResponseWriter, r *http.Request) {
 	h.writeJSONResponse(w, http.StatusOK, note)
 }
 
+// GetNoteLinks lists the notes a note links to and the notes linking to it.
+func (h *NoteHandler) GetNoteLinks(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+	h.logger.Info("Received request to get note links", slog.String("note_id_str", idStr))
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid note ID format", slog.String("note_id_str", idStr), slog.Any("error", err))
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid note ID")
+		return
+	}
+
+	links, err := h.service.GetNoteLinks(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to retrieve note links", slog.Any("note_id", id), slog.Any("error", err))
+		if noteErrorContainsNotFound(err.Error()) {
+			h.writeErrorResponse(w, http.StatusNotFound, err.Error())
+		} else {
+			h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve note links")
+		}
+		return
+	}
+
+	h.logger.Info("Note links retrieved successfully", slog.Any("note_id", id))
+	h.writeJSONResponse(w, http.StatusOK, links)
+}
+
+func (h *NoteHandler) GetNoteChunks(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+	h.logger.Info("Received request to get note chunks", slog.String("note_id_str", idStr))
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid note ID format", slog.String("note_id_str", idStr), slog.Any("error", err))
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid note ID")
+		return
+	}
+
+	chunks, err := h.service.GetNoteChunks(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to retrieve note chunks", slog.Any("note_id", id), slog.Any("error", err))
+		if noteErrorContainsNotFound(err.Error()) {
+			h.writeErrorResponse(w, http.StatusNotFound, err.Error())
+		} else {
+			h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve note chunks")
+		}
+		return
+	}
+
+	h.logger.Info("Note chunks retrieved successfully", slog.Any("note_id", id), slog.Int("count", len(chunks)))
+	h.writeJSONResponse(w, http.StatusOK, chunks)
+}
+
 func (h *NoteHandler) UpdateNote(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
@@ -108,7 +163,7 @@ func (h *NoteHandler) UpdateNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	note, err := h.service.UpdateNote(id, &req)
+	note, err := h.service.UpdateNote(r.Context(), id, &req)
 	if err != nil {
 		h.logger.Error("Failed to update note", slog.Any("note_id", id), slog.Any("error", err))
 		if noteErrorContainsNotFound(err.Error()) {
@@ -134,7 +189,7 @@ func (h *NoteHandler) DeleteNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.DeleteNote(id)
+	err = h.service.DeleteNote(r.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to delete note", slog.Any("note_id", id), slog.Any("error", err))
 		if noteErrorContainsNotFound(err.Error()) {
@@ -150,7 +205,7 @@ func (h *NoteHandler) DeleteNote(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *NoteHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, data any) {
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(statusCode)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		h.logger.Error("Failed to write JSON response", slog.Any("error", err))
@@ -158,7 +213,7 @@ func (h *NoteHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, d
 }
 
 func (h *NoteHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(statusCode)
 	if err := json.NewEncoder(w).Encode(map[string]string{"error": message}); err != nil {
 		h.logger.Error("Failed to write error response", slog.Any("error", err))
@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/gorilla/mux"
 	"go-ai-eng-flashcards/models"
 	"go-ai-eng-flashcards/services"
 	"log/slog"
 	"net/http"
+	"strings"
 )
 
 // quizRequest is the expected structure of the request body for the /quiz endpoint.
@@ -44,7 +46,7 @@ func (h *QuizHandler) GenerateQuizHandler(w http.ResponseWriter, r *http.Request
 	}
 
 	// Call the service to get the updated message list.
-	updatedMessages := h.service.GenerateQuizTurn(req.Messages)
+	updatedMessages := h.service.GenerateQuizTurn(r.Context(), req.Messages)
 
 	// Prepare the response using the local response struct.
 	res := quizResponse{
@@ -55,12 +57,56 @@ func (h *QuizHandler) GenerateQuizHandler(w http.ResponseWriter, r *http.Request
 	h.writeJSONResponse(w, http.StatusOK, res)
 }
 
+// quizResponseV2 carries only the new assistant message; the client already
+// has the rest of the conversation.
+type quizResponseV2 struct {
+	Reply models.Message `json:"reply"`
+}
+
+// GenerateQuizHandlerV2 handles a quiz turn for the v2 API. Unlike v1 it
+// rejects malformed conversations instead of passing them to the LLM, and
+// returns just the reply rather than echoing the whole conversation back.
+func (h *QuizHandler) GenerateQuizHandlerV2(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Received request to generate a v2 quiz turn")
+	var req quizRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Invalid request body for GenerateQuizHandlerV2", slog.Any("error", err))
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	for i, message := range req.Messages {
+		if message.Role != "user" && message.Role != "assistant" {
+			h.logger.Error("Invalid message role", slog.Int("index", i), slog.String("role", message.Role))
+			h.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("messages[%d].role must be user or assistant", i))
+			return
+		}
+		if strings.TrimSpace(message.Content) == "" {
+			h.logger.Error("Empty message content", slog.Int("index", i))
+			h.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("messages[%d].content cannot be empty", i))
+			return
+		}
+	}
+
+	updatedMessages := h.service.GenerateQuizTurn(r.Context(), req.Messages)
+	reply := updatedMessages[len(updatedMessages)-1]
+
+	h.logger.Info("v2 quiz turn generated successfully")
+	h.writeJSONResponse(w, http.StatusOK, quizResponseV2{Reply: reply})
+}
+
+// RegisterRoutes registers the v1 quiz routes.
 func (h *QuizHandler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/quiz", h.GenerateQuizHandler).Methods("POST")
 }
 
+// RegisterV2Routes registers the v2 quiz routes, which are served alongside v1.
+func (h *QuizHandler) RegisterV2Routes(router *mux.Router) {
+	router.HandleFunc("/quiz", h.GenerateQuizHandlerV2).Methods("POST")
+}
+
 func (h *QuizHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, data any) {
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(statusCode)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		h.logger.Error("Failed to write JSON response", slog.Any("error", err))
@@ -68,7 +114,7 @@ func (h *QuizHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, d
 }
 
 func (h *QuizHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(statusCode)
 	if err := json.NewEncoder(w).Encode(map[string]string{"error": message}); err != nil {
 		h.logger.Error("Failed to write error response", slog.Any("error", err))
@@ -34,7 +34,7 @@ func (h *TodoHandler) CreateTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	todo, err := h.service.CreateTodo(&req)
+	todo, err := h.service.CreateTodo(r.Context(), &req)
 	if err != nil {
 		h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
@@ -44,7 +44,7 @@ func (h *TodoHandler) CreateTodo(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *TodoHandler) GetAllTodos(w http.ResponseWriter, r *http.Request) {
-	todos, err := h.service.GetAllTodos()
+	todos, err := h.service.GetAllTodos(r.Context())
 	if err != nil {
 		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve todos")
 		return
@@ -61,7 +61,7 @@ func (h *TodoHandler) GetTodoByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	todo, err := h.service.GetTodoByID(id)
+	todo, err := h.service.GetTodoByID(r.Context(), id)
 	if err != nil {
 		if containsNotFound(err.Error()) {
 			h.writeErrorResponse(w, http.StatusNotFound, err.Error())
@@ -88,7 +88,7 @@ func (h *TodoHandler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	todo, err := h.service.UpdateTodo(id, &req)
+	todo, err := h.service.UpdateTodo(r.Context(), id, &req)
 	if err != nil {
 		if containsNotFound(err.Error()) {
 			h.writeErrorResponse(w, http.StatusNotFound, err.Error())
@@ -109,7 +109,7 @@ func (h *TodoHandler) DeleteTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.DeleteTodo(id)
+	err = h.service.DeleteTodo(r.Context(), id)
 	if err != nil {
 		if containsNotFound(err.Error()) {
 			h.writeErrorResponse(w, http.StatusNotFound, err.Error())
@@ -123,13 +123,13 @@ func (h *TodoHandler) DeleteTodo(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *TodoHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, data any) {
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(data)
 }
 
 func (h *TodoHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(map[string]string{"error": message})
 }
@@ -0,0 +1,179 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go-ai-eng-flashcards/anki"
+)
+
+// AnkiSourcePrefix starts the SourcePath of every note imported from Anki;
+// the rest is the Anki note's GUID.
+const AnkiSourcePrefix = "anki:"
+
+// exportedGUIDPrefix starts the GUID of a note exported to Anki that did
+// not come from Anki; the rest is the note's ID.
+const exportedGUIDPrefix = "flashcards-"
+
+// ExportedGUID is the GUID a note that did not come from Anki is exported
+// with.
+func ExportedGUID(id int) string {
+	return exportedGUIDPrefix + strconv.Itoa(id)
+}
+
+// exportedNoteID returns the ID of the note an Anki GUID was exported from,
+// or 0 if the note was not exported by ExportedGUID.
+func exportedNoteID(guid string) int {
+	id, err := strconv.Atoi(strings.TrimPrefix(guid, exportedGUIDPrefix))
+	if err != nil || id <= 0 || !strings.HasPrefix(guid, exportedGUIDPrefix) {
+		return 0
+	}
+	return id
+}
+
+// AnkiMetadataKey is the metadata key under which an imported note keeps
+// what Anki knew about it, as AnkiMetadata.
+const AnkiMetadataKey = "anki"
+
+// AnkiMetadata is what a note imported from Anki remembers about its
+// source, so exporting it again can keep its GUID, deck and scheduling.
+type AnkiMetadata struct {
+	GUID     string      `json:"guid"`
+	NoteType string      `json:"note_type"`
+	Cloze    bool        `json:"cloze,omitempty"`
+	Deck     string      `json:"deck,omitempty"`
+	Cards    []anki.Card `json:"cards,omitempty"`
+}
+
+// ParseAnkiMetadata reads the AnkiMetadata stored in a note's metadata. It
+// returns nil if the note did not come from Anki.
+func ParseAnkiMetadata(metadata map[string]any) (*AnkiMetadata, error) {
+	value, ok := metadata[AnkiMetadataKey]
+	if !ok {
+		return nil, nil
+	}
+	// Metadata read back from the database is plain JSON values, so go
+	// through JSON rather than asserting on the type.
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Anki metadata: %w", err)
+	}
+	var meta AnkiMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to decode Anki metadata: %w", err)
+	}
+	return &meta, nil
+}
+
+// ParseAnkiPackage turns every note of an .apkg file into one note.
+//
+// For standard note types the first field becomes the title and the other
+// fields the content; cloze notes keep their {{c1::...}} text as the
+// content. Field HTML is converted to plain text. Anki tags become tags,
+// with "::" between levels turned into "/", and so do the parts of the
+// deck name. The note's GUID is its SourcePath, so importing the same deck
+// again updates the notes. A note exported from here rather than first
+// imported from Anki carries its ID instead, and updates the note it came
+// from.
+func ParseAnkiPackage(file File) ([]Note, error) {
+	ankiNotes, err := anki.ReadPackage(file.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	notes := make([]Note, 0, len(ankiNotes))
+	for _, an := range ankiNotes {
+		note := Note{
+			Source:     fmt.Sprintf("%s#%s", file.Name, an.GUID),
+			SourcePath: AnkiSourcePrefix + an.GUID,
+			NoteID:     exportedNoteID(an.GUID),
+			Tags:       ankiTags(an),
+		}
+		note.Title, note.Content = ankiText(an)
+
+		meta, err := jsonObject(AnkiMetadata{
+			GUID:     an.GUID,
+			NoteType: an.NoteType,
+			Cloze:    an.Kind == anki.KindCloze,
+			Deck:     an.Deck,
+			Cards:    an.Cards,
+		})
+		if err != nil {
+			return nil, err
+		}
+		note.Metadata = map[string]any{AnkiMetadataKey: meta}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+// ankiText builds the title and content of an Anki note from its fields.
+// Fields after the second are labelled with their names, since they have no
+// obvious place on a two-sided card.
+func ankiText(an anki.Note) (title, content string) {
+	var texts []string
+	for _, field := range an.Fields {
+		texts = append(texts, anki.HTMLToText(field.Value))
+	}
+	if len(texts) == 0 {
+		return "", ""
+	}
+
+	first, rest := texts[0], texts[1:]
+	if an.Kind != anki.KindCloze && first != "" && !strings.Contains(first, "\n") && utf8.RuneCountInString(first) <= maxTitle {
+		title = first
+	} else {
+		rest = texts
+	}
+
+	var parts []string
+	for i, text := range rest {
+		if text == "" {
+			continue
+		}
+		// rest starts at field 0 or 1; label every field after the second.
+		index := i + len(texts) - len(rest)
+		if index >= 2 {
+			text = an.Fields[index].Name + ": " + text
+		}
+		parts = append(parts, text)
+	}
+	content = strings.Join(parts, "\n\n")
+	if content == "" && title != "" {
+		// A card with only a front is still worth keeping.
+		title, content = "", title
+	}
+	return title, content
+}
+
+func ankiTags(an anki.Note) []string {
+	var tags []string
+	if an.Deck != "" && an.Deck != "Default" {
+		for _, part := range strings.Split(an.Deck, "::") {
+			if tag := Slug(part); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	for _, tag := range an.Tags {
+		tags = append(tags, strings.ToLower(strings.ReplaceAll(tag, "::", "/")))
+	}
+	return mergeTags(tags)
+}
+
+// jsonObject converts v into the map encoding/json would decode it to, so
+// metadata looks the same in memory as after a round trip through storage.
+func jsonObject(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	var object map[string]any
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata: %w", err)
+	}
+	return object, nil
+}
@@ -0,0 +1,318 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// HeaderMode selects whether the first row of a card file names its columns.
+type HeaderMode string
+
+const (
+	// HeaderAuto treats the first row as a header if its cells are column
+	// names such as "term" and "definition".
+	HeaderAuto HeaderMode = "auto"
+	HeaderYes  HeaderMode = "yes"
+	HeaderNo   HeaderMode = "no"
+)
+
+// ParseHeaderMode validates a header mode given by a client; empty means auto.
+func ParseHeaderMode(s string) (HeaderMode, error) {
+	switch mode := HeaderMode(strings.ToLower(s)); mode {
+	case "":
+		return HeaderAuto, nil
+	case HeaderAuto, HeaderYes, HeaderNo:
+		return mode, nil
+	case "true":
+		return HeaderYes, nil
+	case "false":
+		return HeaderNo, nil
+	}
+	return "", fmt.Errorf("header must be one of auto, yes, no, got %q", s)
+}
+
+// delimiterNames are the names a client may give a delimiter by, besides
+// the character itself.
+var delimiterNames = map[string]rune{
+	"tab":       '\t',
+	`\t`:        '\t',
+	"comma":     ',',
+	"semicolon": ';',
+	"pipe":      '|',
+}
+
+// ParseDelimiter validates a column delimiter given by a client, either a
+// single character or one of tab, comma, semicolon and pipe. Empty means
+// detect it from the file, returned as 0.
+func ParseDelimiter(s string) (rune, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if r, ok := delimiterNames[strings.ToLower(s)]; ok {
+		return r, nil
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	if size != len(s) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		return 0, fmt.Errorf("delimiter must be a single character other than a quote or line break, or one of tab, comma, semicolon, pipe, got %q", s)
+	}
+	return r, nil
+}
+
+// DelimiterName is the name of a delimiter as reported back to clients.
+func DelimiterName(r rune) string {
+	if r == '\t' {
+		return "tab"
+	}
+	return string(r)
+}
+
+// ParseEncoding looks up a text encoding by any of its WHATWG names, such as
+// "utf-8", "utf-16le", "windows-1252" or "latin1". Empty means detect it
+// from the file, returned as nil.
+func ParseEncoding(s string) (encoding.Encoding, error) {
+	if s == "" {
+		return nil, nil
+	}
+	enc, err := htmlindex.Get(s)
+	if err != nil {
+		return nil, fmt.Errorf("unknown encoding %q", s)
+	}
+	return enc, nil
+}
+
+// CardOptions controls ParseCards. The zero value detects everything.
+type CardOptions struct {
+	// Delimiter separates the columns; 0 detects it from the file.
+	Delimiter rune
+	Header    HeaderMode
+	// Encoding is the file's text encoding; nil detects it from the file.
+	Encoding encoding.Encoding
+}
+
+// CardFile is the notes of one card file, with the format they were read in.
+type CardFile struct {
+	Notes     []Note
+	Delimiter rune
+	Encoding  string
+	// Header is the first row when it was used as a header.
+	Header []string
+	// Rows counts the rows read, including the header and rows with empty
+	// cells; blank lines are not rows.
+	Rows int
+}
+
+var (
+	termColumns       = []string{"term", "front", "question", "word", "prompt", "side 1"}
+	definitionColumns = []string{"definition", "back", "answer", "meaning", "side 2"}
+	tagColumns        = []string{"tags", "tag"}
+)
+
+// ParseCards reads a file of term/definition pairs, one per row, such as a
+// Quizlet export or a two-column spreadsheet saved as CSV or TSV. Each row
+// becomes a note titled by its term, with the definition as the content.
+// Terms too long or multi-line to be a title are put at the top of the
+// content instead.
+//
+// Without a header the first column is the term and the rest is the
+// definition, joined back together with the delimiter: Quizlet does not
+// quote its exports, so a definition containing the delimiter would
+// otherwise be cut short. A header picks the term, definition and optional
+// tags columns by name. Rows with a term but no definition are kept, so
+// that storing them reports the missing content.
+func ParseCards(file File, opts CardOptions) (*CardFile, error) {
+	text, encName, err := decodeText(file.Data, opts.Encoding)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file.Name, err)
+	}
+
+	delimiter := opts.Delimiter
+	if delimiter == 0 {
+		delimiter = detectDelimiter(file.Name, text)
+	}
+
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	result := &CardFile{Delimiter: delimiter, Encoding: encName}
+	columns := cardColumns{term: 0, definition: 1, tags: -1, rest: true}
+	folders := folderTags(file.Name)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		result.Rows++
+		line, _ := reader.FieldPos(0)
+
+		if result.Rows == 1 {
+			if header, ok := headerColumns(record, opts.Header); ok {
+				result.Header = record
+				columns = header
+				continue
+			}
+		}
+
+		term, definition, tags := columns.split(record, delimiter)
+		if term == "" && definition == "" {
+			continue
+		}
+		note := Note{
+			Source:  fmt.Sprintf("%s#%d", file.Name, line),
+			Content: definition,
+			Tags:    mergeTags(folders, tags),
+		}
+		if definition == "" || (!strings.Contains(term, "\n") && utf8.RuneCountInString(term) <= maxTitle) {
+			note.Title = term
+		} else if term != "" {
+			note.Content = term + "\n\n" + definition
+		}
+		result.Notes = append(result.Notes, note)
+	}
+	return result, nil
+}
+
+// cardColumns are the indexes of the columns a card is read from; tags is -1
+// when there is no tags column. With rest set, the definition is every
+// column from its index on.
+type cardColumns struct {
+	term, definition, tags int
+	rest                   bool
+}
+
+func (c cardColumns) split(record []string, delimiter rune) (term, definition string, tags []string) {
+	cell := func(i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	term = strings.TrimSpace(cell(c.term))
+	if c.rest && c.definition < len(record) {
+		definition = strings.TrimSpace(strings.Join(record[c.definition:], string(delimiter)))
+	} else {
+		definition = strings.TrimSpace(cell(c.definition))
+	}
+	for _, tag := range strings.FieldsFunc(cell(c.tags), func(r rune) bool { return r == ',' || r == ' ' || r == ';' }) {
+		tags = append(tags, strings.ToLower(tag))
+	}
+	return term, definition, tags
+}
+
+// headerColumns decides whether record, the first row, is a header and if
+// so which columns hold what. In auto mode it must name both a term and a
+// definition column. A header that names neither, in yes mode, is skipped
+// and the first two columns used.
+func headerColumns(record []string, mode HeaderMode) (cardColumns, bool) {
+	if mode == HeaderNo {
+		return cardColumns{}, false
+	}
+
+	columns := cardColumns{term: -1, definition: -1, tags: -1}
+	for i, cell := range record {
+		name := strings.ToLower(strings.TrimSpace(cell))
+		switch {
+		case columns.term < 0 && slices.Contains(termColumns, name):
+			columns.term = i
+		case columns.definition < 0 && slices.Contains(definitionColumns, name):
+			columns.definition = i
+		case columns.tags < 0 && slices.Contains(tagColumns, name):
+			columns.tags = i
+		}
+	}
+	if columns.term >= 0 && columns.definition >= 0 {
+		return columns, true
+	}
+	if mode == HeaderYes {
+		if columns.term < 0 {
+			columns.term = 0
+		}
+		if columns.definition < 0 {
+			columns.definition = 1
+		}
+		return columns, true
+	}
+	return cardColumns{}, false
+}
+
+// detectDelimiter picks the delimiter of a card file. A tab in the first
+// non-blank line wins, since terms rarely contain one and it is Quizlet's
+// default; otherwise the most frequent of comma, semicolon and pipe. With no
+// candidate in the line, the file extension decides.
+func detectDelimiter(name, text string) rune {
+	for line := range strings.Lines(text) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.ContainsRune(line, '\t') {
+			return '\t'
+		}
+		best, bestCount := rune(0), 0
+		for _, r := range []rune{',', ';', '|'} {
+			if n := strings.Count(line, string(r)); n > bestCount {
+				best, bestCount = r, n
+			}
+		}
+		if best != 0 {
+			return best
+		}
+		break
+	}
+	if strings.EqualFold(path.Ext(name), ".csv") {
+		return ','
+	}
+	return '\t'
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// decodeText converts data to UTF-8 and names the encoding it was in. A byte
+// order mark always wins, so a UTF-16 file saved by Excel reads correctly
+// whatever enc says. Without one and without enc, valid UTF-8 is taken as
+// is and anything else is assumed to be Windows-1252, the encoding Excel
+// and older Windows tools save CSV files in.
+func decodeText(data []byte, enc encoding.Encoding) (string, string, error) {
+	switch {
+	case bytes.HasPrefix(data, utf8BOM):
+		return decodeWith(unicode.UTF8, "utf-8", data[len(utf8BOM):])
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return decodeWith(unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM), "utf-16le", data)
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeWith(unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM), "utf-16be", data)
+	case enc != nil:
+		name, err := htmlindex.Name(enc)
+		if err != nil {
+			name = fmt.Sprint(enc)
+		}
+		return decodeWith(enc, name, data)
+	case utf8.Valid(data):
+		return string(data), "utf-8", nil
+	default:
+		return decodeWith(charmap.Windows1252, "windows-1252", data)
+	}
+}
+
+func decodeWith(enc encoding.Encoding, name string, data []byte) (string, string, error) {
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode %s text: %w", name, err)
+	}
+	return string(decoded), name, nil
+}
@@ -0,0 +1,184 @@
+package importer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/unicode"
+)
+
+// card is the part of a note a card file sets.
+type card struct {
+	title, content string
+	tags           []string
+}
+
+// utf16File encodes text as UTF-16 with a byte order mark, as Excel saves
+// "Unicode text".
+func utf16File(t *testing.T, endianness unicode.Endianness, text string) []byte {
+	t.Helper()
+	data, err := unicode.UTF16(endianness, unicode.UseBOM).NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseCards(t *testing.T) {
+	longTerm := strings.Repeat("term ", 60)
+	latin1, err := ParseEncoding("latin1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		file          string
+		data          []byte
+		opts          CardOptions
+		wantDelimiter rune
+		wantEncoding  string
+		wantHeader    []string
+		want          []card
+	}{
+		{
+			name:          "Quizlet tab-separated, extra columns joined",
+			file:          "biology.txt",
+			data:          []byte("cell\tthe unit of life\tsee chapter 1\nmitosis\tone cell becomes two\n\n"),
+			wantDelimiter: '\t',
+			wantEncoding:  "utf-8",
+			want: []card{
+				{title: "cell", content: "the unit of life\tsee chapter 1"},
+				{title: "mitosis", content: "one cell becomes two"},
+			},
+		},
+		{
+			name:          "Quizlet with a custom delimiter, extra columns joined",
+			file:          "biology.txt",
+			data:          []byte("cell;the unit; of life\nmitosis;division\n"),
+			wantDelimiter: ';',
+			wantEncoding:  "utf-8",
+			want: []card{
+				{title: "cell", content: "the unit; of life"},
+				{title: "mitosis", content: "division"},
+			},
+		},
+		{
+			name:          "CSV with a header",
+			file:          "decks/biology.csv",
+			data:          []byte("Tags,Definition,Term\n\"Bio, Basics\",\"the unit, of life\",cell\n"),
+			wantDelimiter: ',',
+			wantEncoding:  "utf-8",
+			wantHeader:    []string{"Tags", "Definition", "Term"},
+			want:          []card{{title: "cell", content: "the unit, of life", tags: []string{"decks", "bio", "basics"}}},
+		},
+		{
+			name:          "pipes",
+			file:          "biology.txt",
+			data:          []byte("cell|the unit of life\n"),
+			wantDelimiter: '|',
+			wantEncoding:  "utf-8",
+			want:          []card{{title: "cell", content: "the unit of life"}},
+		},
+		{
+			name:          "no delimiter in a CSV file",
+			file:          "biology.CSV",
+			data:          []byte("cell\n"),
+			wantDelimiter: ',',
+			wantEncoding:  "utf-8",
+			want:          []card{{title: "cell"}},
+		},
+		{
+			name:          "header names taken as a card when header is no",
+			file:          "biology.txt",
+			data:          []byte("term\tdefinition\n"),
+			opts:          CardOptions{Header: HeaderNo},
+			wantDelimiter: '\t',
+			wantEncoding:  "utf-8",
+			want:          []card{{title: "term", content: "definition"}},
+		},
+		{
+			name:          "unnamed header skipped when header is yes",
+			file:          "biology.txt",
+			data:          []byte("Biology\tChapter 1\ncell\tthe unit of life\n"),
+			opts:          CardOptions{Header: HeaderYes},
+			wantDelimiter: '\t',
+			wantEncoding:  "utf-8",
+			wantHeader:    []string{"Biology", "Chapter 1"},
+			want:          []card{{title: "cell", content: "the unit of life"}},
+		},
+		{
+			name:          "long terms move into the content",
+			file:          "biology.txt",
+			data:          []byte(longTerm + "\tdefinition\n"),
+			wantDelimiter: '\t',
+			wantEncoding:  "utf-8",
+			want:          []card{{content: strings.TrimSpace(longTerm) + "\n\ndefinition"}},
+		},
+		{
+			name:          "UTF-8 with a byte order mark",
+			file:          "cafe.txt",
+			data:          []byte("\xEF\xBB\xBFcafé\tcoffee\n"),
+			wantDelimiter: '\t',
+			wantEncoding:  "utf-8",
+			want:          []card{{title: "café", content: "coffee"}},
+		},
+		{
+			name:          "UTF-16 little-endian",
+			file:          "cafe.txt",
+			data:          utf16File(t, unicode.LittleEndian, "café\tcoffee\r\nthé\ttea\r\n"),
+			wantDelimiter: '\t',
+			wantEncoding:  "utf-16le",
+			want:          []card{{title: "café", content: "coffee"}, {title: "thé", content: "tea"}},
+		},
+		{
+			name:          "UTF-16 big-endian",
+			file:          "cafe.csv",
+			data:          utf16File(t, unicode.BigEndian, "café,coffee\n"),
+			wantDelimiter: ',',
+			wantEncoding:  "utf-16be",
+			want:          []card{{title: "café", content: "coffee"}},
+		},
+		{
+			name:          "Windows-1252 when not UTF-8",
+			file:          "cafe.csv",
+			data:          []byte("caf\xe9,\x93hot\x94 coffee\n"),
+			wantDelimiter: ',',
+			wantEncoding:  "windows-1252",
+			want:          []card{{title: "café", content: "“hot” coffee"}},
+		},
+		{
+			name:          "encoding given by the client over UTF-8",
+			file:          "cafe.txt",
+			data:          []byte("café\tcoffee\n"),
+			opts:          CardOptions{Encoding: latin1},
+			wantDelimiter: '\t',
+			wantEncoding:  "windows-1252",
+			want:          []card{{title: "cafÃ©", content: "coffee"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCards(File{Name: tt.file, Data: tt.data}, tt.opts)
+			if err != nil {
+				t.Fatalf("ParseCards: %v", err)
+			}
+			if got.Delimiter != tt.wantDelimiter || got.Encoding != tt.wantEncoding {
+				t.Errorf("read as %q in %s, want %q in %s", got.Delimiter, got.Encoding, tt.wantDelimiter, tt.wantEncoding)
+			}
+			if !slices.Equal(got.Header, tt.wantHeader) {
+				t.Errorf("header = %q, want %q", got.Header, tt.wantHeader)
+			}
+			var cards []card
+			for _, note := range got.Notes {
+				cards = append(cards, card{title: note.Title, content: note.Content, tags: note.Tags})
+			}
+			if !slices.EqualFunc(cards, tt.want, func(a, b card) bool {
+				return a.title == b.title && a.content == b.content && slices.Equal(a.tags, b.tags)
+			}) {
+				t.Errorf("cards = %q\nwant %q", cards, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package importer
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"go-ai-eng-flashcards/ingest"
+)
+
+// DocumentMetadataKey is the metadata key under which a note made from an
+// ingested document records where in the document it came from, as
+// DocumentMetadata.
+const DocumentMetadataKey = "document"
+
+// DocumentMetadata locates a note's passage in the document it came from.
+type DocumentMetadata struct {
+	Name  string      `json:"name"`
+	Title string      `json:"title,omitempty"`
+	Type  ingest.Kind `json:"type"`
+	// Section is the heading path of the passage in an HTML document.
+	Section []string `json:"section,omitempty"`
+	// FirstPage and LastPage are the pages of a PDF the passage spans.
+	FirstPage int `json:"first_page,omitempty"`
+	LastPage  int `json:"last_page,omitempty"`
+	// Chunk is the passage's position among the document's Chunks,
+	// counting from 1.
+	Chunk  int `json:"chunk"`
+	Chunks int `json:"chunks"`
+	// Condensed is set when the content is an LLM summary of the passage
+	// rather than its text.
+	Condensed bool `json:"condensed,omitempty"`
+}
+
+// DocumentNotes turns the passages of an ingested document into notes, one
+// per chunk. A note is titled by the heading it is under, else the
+// document's title, else the file name, followed by its pages for a PDF
+// and numbered when several notes would share a title.
+func DocumentNotes(name string, doc *ingest.Document, chunks []ingest.Chunk) ([]Note, error) {
+	titles := make([]string, len(chunks))
+	counts := make(map[string]int)
+	for i, chunk := range chunks {
+		titles[i] = chunkTitle(name, doc, chunk)
+		counts[titles[i]]++
+	}
+
+	notes := make([]Note, 0, len(chunks))
+	seen := make(map[string]int)
+	for i, chunk := range chunks {
+		title := titles[i]
+		if counts[title] > 1 {
+			seen[title]++
+			title = fmt.Sprintf("%s (%d/%d)", title, seen[title], counts[title])
+		}
+
+		meta, err := jsonObject(DocumentMetadata{
+			Name:      name,
+			Title:     doc.Title,
+			Type:      doc.Kind,
+			Section:   chunk.Heading,
+			FirstPage: chunk.FirstPage,
+			LastPage:  chunk.LastPage,
+			Chunk:     i + 1,
+			Chunks:    len(chunks),
+		})
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, Note{
+			Source:   fmt.Sprintf("%s#%d", name, i+1),
+			Title:    truncateTitle(title),
+			Content:  chunk.Text,
+			Metadata: map[string]any{DocumentMetadataKey: meta},
+		})
+	}
+	return notes, nil
+}
+
+// MarkdownDocument reads Markdown text, such as a note's content, as a
+// document with one section per heading, so it can be cut into passages
+// with ingest.Split. Paragraphs end at blank lines; fenced code blocks are
+// kept whole, and thematic breaks are dropped.
+func MarkdownDocument(text string) *ingest.Document {
+	doc := &ingest.Document{Kind: ingest.KindMarkdown}
+
+	var (
+		headings   []string
+		levels     []int
+		paragraphs []string
+		paragraph  []string
+	)
+	endParagraph := func() {
+		if text := strings.TrimSpace(strings.Join(paragraph, "\n")); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+		paragraph = nil
+	}
+	endSection := func() {
+		endParagraph()
+		if len(paragraphs) > 0 {
+			doc.Sections = append(doc.Sections, ingest.Section{Heading: slices.Clone(headings), Paragraphs: paragraphs})
+		}
+		paragraphs = nil
+	}
+
+	for _, l := range scanLines(text) {
+		if level, title, ok := heading(l); ok {
+			endSection()
+			for len(levels) > 0 && levels[len(levels)-1] >= level {
+				levels, headings = levels[:len(levels)-1], headings[:len(headings)-1]
+			}
+			if title != "" {
+				levels, headings = append(levels, level), append(headings, title)
+			}
+			continue
+		}
+		if isSeparator(l) || (!l.inFence && strings.TrimSpace(l.text) == "") {
+			endParagraph()
+			continue
+		}
+		paragraph = append(paragraph, l.text)
+	}
+	endSection()
+	return doc
+}
+
+func chunkTitle(name string, doc *ingest.Document, chunk ingest.Chunk) string {
+	var title string
+	switch {
+	case len(chunk.Heading) > 0:
+		title = chunk.Heading[len(chunk.Heading)-1]
+	case doc.Title != "":
+		title = doc.Title
+	default:
+		title = strings.TrimSuffix(path.Base(name), path.Ext(name))
+	}
+
+	switch {
+	case chunk.FirstPage == 0:
+	case chunk.FirstPage == chunk.LastPage:
+		title = fmt.Sprintf("%s (p. %d)", title, chunk.FirstPage)
+	default:
+		title = fmt.Sprintf("%s (pp. %d-%d)", title, chunk.FirstPage, chunk.LastPage)
+	}
+	return title
+}
+
+// truncateTitle shortens title to maxTitle characters, ending it with an
+// ellipsis if it was cut.
+func truncateTitle(title string) string {
+	if utf8.RuneCountInString(title) <= maxTitle {
+		return title
+	}
+	runes := []rune(title)
+	return strings.TrimSpace(string(runes[:maxTitle-1])) + "…"
+}
@@ -0,0 +1,64 @@
+package importer
+
+import (
+	"slices"
+	"testing"
+
+	"go-ai-eng-flashcards/ingest"
+)
+
+func TestDocumentNotes(t *testing.T) {
+	tests := []struct {
+		name   string
+		doc    ingest.Document
+		chunks []ingest.Chunk
+		want   []string
+	}{
+		{
+			name:   "heading titles",
+			doc:    ingest.Document{Title: "Biology", Kind: ingest.KindHTML},
+			chunks: []ingest.Chunk{{Heading: []string{"Cells", "Mitosis"}}, {Heading: []string{"Cells", "Meiosis"}}},
+			want:   []string{"Mitosis", "Meiosis"},
+		},
+		{
+			name:   "shared titles are numbered",
+			doc:    ingest.Document{Title: "Biology", Kind: ingest.KindHTML},
+			chunks: []ingest.Chunk{{}, {Heading: []string{"Cells"}}, {}},
+			want:   []string{"Biology (1/2)", "Cells", "Biology (2/2)"},
+		},
+		{
+			name:   "file name without a title",
+			doc:    ingest.Document{Kind: ingest.KindHTML},
+			chunks: []ingest.Chunk{{}},
+			want:   []string{"cells"},
+		},
+		{
+			name:   "PDF pages",
+			doc:    ingest.Document{Kind: ingest.KindPDF, Pages: 3},
+			chunks: []ingest.Chunk{{FirstPage: 1, LastPage: 1}, {FirstPage: 2, LastPage: 3}},
+			want:   []string{"cells (p. 1)", "cells (pp. 2-3)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes, err := DocumentNotes("docs/cells.html", &tt.doc, tt.chunks)
+			if err != nil {
+				t.Fatalf("DocumentNotes: %v", err)
+			}
+			var titles []string
+			for i, note := range notes {
+				titles = append(titles, note.Title)
+				meta, ok := note.Metadata[DocumentMetadataKey].(map[string]any)
+				if !ok {
+					t.Fatalf("note %d has no document metadata: %v", i, note.Metadata)
+				}
+				if meta["chunk"] != float64(i+1) || meta["chunks"] != float64(len(tt.chunks)) {
+					t.Errorf("note %d is chunk %v of %v, want %d of %d", i, meta["chunk"], meta["chunks"], i+1, len(tt.chunks))
+				}
+			}
+			if !slices.Equal(titles, tt.want) {
+				t.Errorf("titles = %q, want %q", titles, tt.want)
+			}
+		})
+	}
+}
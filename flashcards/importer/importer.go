@@ -0,0 +1,211 @@
+// Package importer turns uploaded files into notes. It only parses: storing
+// the results, and deciding what to do with the ones that fail validation,
+// is up to the note service.
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"unicode"
+)
+
+const (
+	// MaxFiles caps how many files one upload, including zip contents, may hold.
+	MaxFiles = 1000
+	// MaxFileSize caps the uncompressed size of any single file.
+	MaxFileSize = 10 << 20
+	// MaxUploadSize caps the uncompressed size of all the files of one
+	// upload together, zip contents included.
+	MaxUploadSize = 100 << 20
+)
+
+// ErrUploadTooLarge is returned once the files of an upload add up to more
+// than their Budget allows.
+var ErrUploadTooLarge = errors.New("upload is too large")
+
+// Budget is the uncompressed bytes an upload's files may still take. One
+// Budget is shared by every file and zip archive of an upload, so many
+// entries that are each within MaxFileSize cannot add up to an unbounded
+// amount of memory.
+type Budget struct {
+	remaining int64
+}
+
+// NewBudget returns a Budget of MaxUploadSize bytes.
+func NewBudget() *Budget {
+	return &Budget{remaining: MaxUploadSize}
+}
+
+// Take spends n bytes, or returns ErrUploadTooLarge if fewer are left.
+func (b *Budget) Take(n int) error {
+	if int64(n) > b.remaining {
+		b.remaining = 0
+		return fmt.Errorf("%w: its files add up to more than %d bytes uncompressed", ErrUploadTooLarge, MaxUploadSize)
+	}
+	b.remaining -= int64(n)
+	return nil
+}
+
+// maxTitle is the longest text an importer uses as a note title; longer text
+// stays in the content. It matches the note title limit.
+const maxTitle = 255
+
+// File is one uploaded file. Name is its path inside the upload, using
+// forward slashes, e.g. "biology/cells.md".
+type File struct {
+	Name string
+	Data []byte
+}
+
+// Note is a note parsed from a file, ready to be validated and stored.
+type Note struct {
+	Source   string
+	Title    string
+	Content  string
+	Tags     []string
+	Metadata map[string]any
+	// SourcePath, when set, identifies the note across imports: importing a
+	// note with the same SourcePath again updates it instead of adding a copy.
+	SourcePath string
+	// NoteID, when set, is the ID of the stored note this note was exported
+	// from. Importing it updates that note, if it still exists, in place of
+	// the note with SourcePath.
+	NoteID int
+	// Links are the normalized targets of the note's links to other notes,
+	// and LinkKeys the normalized names other notes may link to it by.
+	Links    []string
+	LinkKeys []string
+}
+
+// ExpandZip returns the regular files inside a zip archive. Directories,
+// macOS resource forks and hidden files are skipped. Sizes are checked
+// against the uncompressed data actually read, not the headers: each file
+// against MaxFileSize and all of them against budget, and reading stops as
+// soon as either is exceeded, so a crafted archive cannot exhaust memory.
+func ExpandZip(data []byte, budget *Budget) ([]File, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip archive: %w", err)
+	}
+
+	var files []File
+	for _, entry := range archive.File {
+		name := path.Clean(strings.ReplaceAll(entry.Name, "\\", "/"))
+		if entry.FileInfo().IsDir() || hidden(name) {
+			continue
+		}
+		if len(files) == MaxFiles {
+			return nil, fmt.Errorf("zip archive contains more than %d files", MaxFiles)
+		}
+
+		content, err := readZipEntry(entry, budget)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Name: name, Data: content})
+	}
+	return files, nil
+}
+
+func readZipEntry(entry *zip.File, budget *Budget) ([]byte, error) {
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s in zip archive: %w", entry.Name, err)
+	}
+	defer rc.Close()
+
+	// Read one byte past whichever limit is closer, to tell that it was hit.
+	limit := min(MaxFileSize, budget.remaining)
+	content, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s in zip archive: %w", entry.Name, err)
+	}
+	if len(content) > MaxFileSize {
+		return nil, fmt.Errorf("%s exceeds the %d byte file limit", entry.Name, MaxFileSize)
+	}
+	if err := budget.Take(len(content)); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// hidden reports whether any element of name starts with a dot, or is the
+// __MACOSX folder that macOS adds to archives it creates.
+func hidden(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// IsMarkdown reports whether name has a Markdown file extension.
+func IsMarkdown(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+// IsCardFile reports whether name has the extension of a delimited text
+// file that ParseCards can read.
+func IsCardFile(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".csv", ".tsv", ".tab", ".txt":
+		return true
+	}
+	return false
+}
+
+// IsZip reports whether name has a zip file extension.
+func IsZip(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".zip")
+}
+
+// Slug lowercases s and replaces every run of characters other than letters
+// and digits with a single dash, for use as a tag.
+func Slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+// folderTags turns the directories of a file's path into tags, so
+// "biology/cells/mitosis.md" is tagged biology and cells.
+func folderTags(name string) []string {
+	dir := path.Dir(name)
+	if dir == "." || dir == "/" {
+		return nil
+	}
+	var tags []string
+	for _, part := range strings.Split(strings.Trim(dir, "/"), "/") {
+		if tag := Slug(part); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// baseTitle is a file name without its directory or extension.
+func baseTitle(name string) string {
+	base := path.Base(name)
+	return strings.TrimSuffix(base, path.Ext(base))
+}
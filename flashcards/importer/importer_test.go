@@ -0,0 +1,90 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// zeroZip returns a zip archive of n files of size zero bytes each, which
+// deflate to almost nothing.
+func zeroZip(t *testing.T, n, size int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	zeros := make([]byte, size)
+	for i := range n {
+		w, err := archive.Create(fmt.Sprintf("notes/%d.md", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(zeros); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExpandZipBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		budget int64
+		zips   [][]byte
+		// wantErr is nil for success, or the error wanted: ErrUploadTooLarge,
+		// or errFileLimit for the per-file limit.
+		wantErr error
+	}{
+		{name: "within budget", budget: 1 << 20, zips: [][]byte{zeroZip(t, 4, 200<<10)}},
+		{name: "one archive over budget", budget: 1 << 20, zips: [][]byte{zeroZip(t, 4, 300<<10)}, wantErr: ErrUploadTooLarge},
+		{
+			// Each archive fits on its own; together they do not.
+			name:    "budget shared across archives",
+			budget:  1 << 20,
+			zips:    [][]byte{zeroZip(t, 2, 300<<10), zeroZip(t, 2, 300<<10)},
+			wantErr: ErrUploadTooLarge,
+		},
+		{name: "file over MaxFileSize", budget: MaxUploadSize, zips: [][]byte{zeroZip(t, 1, MaxFileSize+1)}, wantErr: errFileLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := &Budget{remaining: tt.budget}
+			var err error
+			for _, data := range tt.zips {
+				if _, err = ExpandZip(data, budget); err != nil {
+					break
+				}
+			}
+			switch tt.wantErr {
+			case errFileLimit:
+				if err == nil || errors.Is(err, ErrUploadTooLarge) {
+					t.Fatalf("err = %v, want the per-file limit", err)
+				}
+			default:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+// errFileLimit stands for the per-file limit error in test tables, which
+// has no sentinel of its own.
+var errFileLimit = errors.New("file limit")
+
+func TestExpandZipHighRatioArchive(t *testing.T) {
+	// Eleven files, each within MaxFileSize, inflate past MaxUploadSize
+	// from an archive of a few hundred kilobytes.
+	data := zeroZip(t, MaxUploadSize/MaxFileSize+1, MaxFileSize)
+	if len(data) > 1<<20 {
+		t.Fatalf("archive is %d bytes, want a small one", len(data))
+	}
+	if _, err := ExpandZip(data, NewBudget()); !errors.Is(err, ErrUploadTooLarge) {
+		t.Fatalf("err = %v, want ErrUploadTooLarge", err)
+	}
+}
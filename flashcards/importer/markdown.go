@@ -0,0 +1,280 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// SplitMode selects how a Markdown document is divided into notes.
+type SplitMode string
+
+const (
+	// SplitAuto splits on separators if the document has any, else on headings.
+	SplitAuto SplitMode = "auto"
+	// SplitHeading starts a new note at every heading up to MarkdownOptions.HeadingLevel.
+	SplitHeading SplitMode = "heading"
+	// SplitSeparator starts a new note at every thematic break (---, *** or ___).
+	SplitSeparator SplitMode = "separator"
+)
+
+// ParseSplitMode validates a split mode given by a client; empty means auto.
+func ParseSplitMode(s string) (SplitMode, error) {
+	switch mode := SplitMode(strings.ToLower(s)); mode {
+	case "":
+		return SplitAuto, nil
+	case SplitAuto, SplitHeading, SplitSeparator:
+		return mode, nil
+	}
+	return "", fmt.Errorf("split must be one of auto, heading, separator, got %q", s)
+}
+
+// MarkdownOptions controls SplitMarkdown.
+type MarkdownOptions struct {
+	Split SplitMode
+	// HeadingLevel is the deepest heading (1-6) that starts a new note when
+	// splitting by heading; deeper headings stay inside the note body.
+	HeadingLevel int
+}
+
+// DefaultMarkdownOptions splits on separators when present and otherwise on
+// headings down to ##, which suits one-topic-per-section study notes.
+var DefaultMarkdownOptions = MarkdownOptions{Split: SplitAuto, HeadingLevel: 2}
+
+var (
+	headingPattern   = regexp.MustCompile(`^(#{1,6})[ \t]+(.*?)[ \t]*#*[ \t]*$`)
+	separatorPattern = regexp.MustCompile(`^[ ]{0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	fencePattern     = regexp.MustCompile("^[ ]{0,3}(`{3,}|~{3,})")
+)
+
+type line struct {
+	text string
+	// inFence is true for lines inside (or delimiting) a fenced code block,
+	// where headings and separators are just text.
+	inFence bool
+}
+
+// SplitMarkdown divides a Markdown file into notes.
+//
+// When splitting by heading, each section's heading becomes the note title
+// and the headings above it become tags, along with the file's folders. Text
+// before the first heading becomes a note titled after the file. When
+// splitting by separator, a section that starts with a heading uses it as the
+// title. Sections with no body are skipped. A leading YAML frontmatter block
+// is not part of any note.
+func SplitMarkdown(file File, opts MarkdownOptions) []Note {
+	_, body := splitFrontmatter(string(file.Data))
+	lines := scanLines(body)
+
+	mode := opts.Split
+	if mode == SplitAuto || mode == "" {
+		mode = SplitHeading
+		if slices.ContainsFunc(lines, isSeparator) {
+			mode = SplitSeparator
+		}
+	}
+
+	level := opts.HeadingLevel
+	if level < 1 || level > 6 {
+		level = DefaultMarkdownOptions.HeadingLevel
+	}
+
+	var notes []Note
+	if mode == SplitSeparator {
+		notes = splitBySeparator(file, lines)
+	} else {
+		notes = splitByHeading(file, lines, level)
+	}
+
+	for i := range notes {
+		notes[i].Source = fmt.Sprintf("%s#%d", file.Name, i+1)
+		notes[i].Tags = mergeTags(folderTags(file.Name), notes[i].Tags)
+	}
+	return notes
+}
+
+// SplitMarkdownFiles splits every Markdown file in files and returns the
+// files it skipped because they are not Markdown.
+func SplitMarkdownFiles(files []File, opts MarkdownOptions) (notes []Note, skipped []File) {
+	for _, file := range files {
+		if !IsMarkdown(file.Name) {
+			skipped = append(skipped, file)
+			continue
+		}
+		notes = append(notes, SplitMarkdown(file, opts)...)
+	}
+	return notes, skipped
+}
+
+func splitByHeading(file File, lines []line, maxLevel int) []Note {
+	var (
+		notes   []Note
+		parents []string // heading text for each level above the current section
+		title   = baseTitle(file.Name)
+		tags    []string
+		section strings.Builder
+	)
+
+	flush := func() {
+		if content := strings.TrimSpace(section.String()); content != "" {
+			notes = append(notes, Note{Title: title, Content: content, Tags: tags})
+		}
+		section.Reset()
+	}
+
+	for _, l := range lines {
+		level, text, ok := heading(l)
+		if !ok || level > maxLevel {
+			section.WriteString(l.text)
+			section.WriteByte('\n')
+			continue
+		}
+
+		flush()
+		// Headings at this level or deeper are no longer ancestors.
+		parents = parents[:min(len(parents), level-1)]
+		for len(parents) < level-1 {
+			parents = append(parents, "")
+		}
+
+		title = text
+		tags = nil
+		for _, parent := range parents {
+			if tag := Slug(parent); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		parents = append(parents, text)
+	}
+	flush()
+	return notes
+}
+
+func splitBySeparator(file File, lines []line) []Note {
+	var (
+		notes   []Note
+		section []line
+	)
+
+	flush := func() {
+		title := ""
+		body := section
+		// Skip blank lines so a heading right after a separator is found.
+		for len(body) > 0 && strings.TrimSpace(body[0].text) == "" {
+			body = body[1:]
+		}
+		if len(body) > 0 {
+			if _, text, ok := heading(body[0]); ok {
+				title = text
+				body = body[1:]
+			}
+		}
+
+		var content strings.Builder
+		for _, l := range body {
+			content.WriteString(l.text)
+			content.WriteByte('\n')
+		}
+		if text := strings.TrimSpace(content.String()); text != "" {
+			notes = append(notes, Note{Title: title, Content: text})
+		}
+		section = section[:0]
+	}
+
+	for _, l := range lines {
+		if isSeparator(l) {
+			flush()
+			continue
+		}
+		section = append(section, l)
+	}
+	flush()
+	return notes
+}
+
+// scanLines splits text into lines and marks which ones are inside fenced
+// code blocks.
+func scanLines(text string) []line {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	raw := strings.Split(text, "\n")
+	lines := make([]line, len(raw))
+
+	fence := ""
+	for i, l := range raw {
+		if fence != "" {
+			lines[i] = line{text: l, inFence: true}
+			if m := fencePattern.FindStringSubmatch(l); m != nil && m[1][0] == fence[0] && len(m[1]) >= len(fence) {
+				fence = ""
+			}
+			continue
+		}
+		if m := fencePattern.FindStringSubmatch(l); m != nil {
+			fence = m[1]
+			lines[i] = line{text: l, inFence: true}
+			continue
+		}
+		lines[i] = line{text: l}
+	}
+	return lines
+}
+
+func heading(l line) (int, string, bool) {
+	if l.inFence {
+		return 0, "", false
+	}
+	m := headingPattern.FindStringSubmatch(l.text)
+	if m == nil {
+		return 0, "", false
+	}
+	return len(m[1]), strings.TrimSpace(m[2]), true
+}
+
+func isSeparator(l line) bool {
+	return !l.inFence && separatorPattern.MatchString(l.text)
+}
+
+// splitFrontmatter separates a leading "---" delimited YAML block from the
+// rest of the document. Without one, frontmatter is empty.
+func splitFrontmatter(text string) (frontmatter, body string) {
+	text = strings.TrimPrefix(text, "\uFEFF")
+	rest, ok := strings.CutPrefix(text, "---\n")
+	if !ok {
+		rest, ok = strings.CutPrefix(text, "---\r\n")
+	}
+	if !ok {
+		return "", text
+	}
+
+	for offset := 0; offset < len(rest); {
+		end := strings.IndexByte(rest[offset:], '\n')
+		lineEnd := len(rest)
+		if end >= 0 {
+			lineEnd = offset + end
+		}
+		if l := strings.TrimRight(rest[offset:lineEnd], "\r"); l == "---" || l == "..." {
+			if lineEnd == len(rest) {
+				return rest[:offset], ""
+			}
+			return rest[:offset], rest[lineEnd+1:]
+		}
+		if end < 0 {
+			break
+		}
+		offset = lineEnd + 1
+	}
+	// An unterminated block is treated as ordinary text.
+	return "", text
+}
+
+func mergeTags(groups ...[]string) []string {
+	var tags []string
+	for _, group := range groups {
+		for _, tag := range group {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
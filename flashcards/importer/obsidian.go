@@ -0,0 +1,257 @@
+package importer
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ObsidianSourcePrefix starts the SourcePath of every note imported from an
+// Obsidian vault.
+const ObsidianSourcePrefix = "obsidian:"
+
+var (
+	wikiLinkPattern  = regexp.MustCompile(`(!?)\[\[([^\[\]\n]+)\]\]`)
+	inlineTagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_/-]*[\p{L}_/-][\p{L}\p{N}_/-]*)`)
+)
+
+// Vault is the result of reading an Obsidian vault.
+type Vault struct {
+	// Name is the vault name used in each note's SourcePath.
+	Name  string
+	Notes []Note
+	// Skipped are the files that are not Markdown, such as attachments.
+	Skipped []File
+}
+
+// ParseObsidianVault turns every Markdown file of a vault into one note.
+//
+// The title is the frontmatter title, or else the file name. Frontmatter
+// tags, inline #tags and the file's folders become tags, and every other
+// frontmatter key is kept as metadata. [[Wiki links]] to other notes are
+// returned in Links for the caller to resolve.
+//
+// Each note's SourcePath is built from the vault name and the file's path
+// inside the vault, so importing the same vault again finds the same notes.
+// When name is empty and every file sits in one top-level folder, as in a
+// zip of the vault folder, that folder is the vault name; it is never part
+// of the note paths.
+func ParseObsidianVault(files []File, name string) (*Vault, error) {
+	root := commonRoot(files)
+	if name == "" {
+		name = root
+	}
+	name = strings.Trim(name, "/")
+
+	vault := &Vault{Name: name}
+	for _, file := range files {
+		if !IsMarkdown(file.Name) {
+			vault.Skipped = append(vault.Skipped, file)
+			continue
+		}
+		rel := strings.TrimPrefix(file.Name, root+"/")
+		if root == "" {
+			rel = file.Name
+		}
+
+		note, err := parseObsidianNote(File{Name: rel, Data: file.Data})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file.Name, err)
+		}
+		note.SourcePath = ObsidianSourcePrefix + path.Join(name, rel)
+		vault.Notes = append(vault.Notes, note)
+	}
+	return vault, nil
+}
+
+// commonRoot returns the top-level folder shared by every file, if any.
+func commonRoot(files []File) string {
+	root := ""
+	for _, file := range files {
+		first, _, ok := strings.Cut(file.Name, "/")
+		if !ok || (root != "" && first != root) {
+			return ""
+		}
+		root = first
+	}
+	return root
+}
+
+// ParseMarkdownNote turns one Markdown file into one note the way a vault
+// note is read: the frontmatter title, tags and metadata, inline #tags and
+// the file's folders as tags. Wiki links are left out, since they can only
+// be resolved against the other notes of an upload. SourcePath is not set.
+func ParseMarkdownNote(file File) (Note, error) {
+	note, err := parseObsidianNote(file)
+	note.Links, note.LinkKeys = nil, nil
+	return note, err
+}
+
+func parseObsidianNote(file File) (Note, error) {
+	frontmatter, body := splitFrontmatter(string(file.Data))
+
+	fields := map[string]any{}
+	if strings.TrimSpace(frontmatter) != "" {
+		if err := yaml.Unmarshal([]byte(frontmatter), &fields); err != nil {
+			return Note{}, fmt.Errorf("invalid frontmatter: %w", err)
+		}
+	}
+
+	note := Note{
+		Source:   file.Name,
+		Title:    baseTitle(file.Name),
+		Content:  strings.TrimSpace(body),
+		Metadata: map[string]any{},
+	}
+	if title, ok := fields["title"].(string); ok && strings.TrimSpace(title) != "" {
+		note.Title = strings.TrimSpace(title)
+	}
+
+	var tags []string
+	for _, key := range []string{"tags", "tag"} {
+		tags = append(tags, stringList(fields[key])...)
+	}
+	lines := scanLines(body)
+	tags = append(tags, inlineTags(lines)...)
+	for i, tag := range tags {
+		tags[i] = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	}
+	note.Tags = mergeTags(folderTags(file.Name), slices.DeleteFunc(tags, func(tag string) bool { return tag == "" }))
+
+	// The link keys are every name another note may use to link here.
+	withoutExt := strings.TrimSuffix(file.Name, path.Ext(file.Name))
+	note.LinkKeys = mergeTags([]string{linkKey(withoutExt), linkKey(path.Base(withoutExt))})
+	for _, key := range []string{"aliases", "alias"} {
+		for _, alias := range stringList(fields[key]) {
+			if aliasKey := linkKey(alias); aliasKey != "" {
+				note.LinkKeys = mergeTags(note.LinkKeys, []string{aliasKey})
+			}
+		}
+	}
+
+	note.Links = wikiLinks(lines)
+
+	for key, value := range fields {
+		switch key {
+		case "title", "tags", "tag":
+			continue
+		}
+		note.Metadata[key] = jsonValue(value)
+	}
+	return note, nil
+}
+
+// wikiLinks returns the normalized targets of the [[links]] outside code
+// blocks, without duplicates. Embeds of attachments, such as ![[cell.png]],
+// are not links between notes and are left out.
+func wikiLinks(lines []line) []string {
+	var links []string
+	for _, l := range lines {
+		if l.inFence {
+			continue
+		}
+		for _, m := range wikiLinkPattern.FindAllStringSubmatch(l.text, -1) {
+			target, _, _ := strings.Cut(m[2], "|")
+			target, _, _ = strings.Cut(target, "#")
+			target, _, _ = strings.Cut(target, "^")
+			target = strings.TrimSpace(target)
+			if ext := path.Ext(target); m[1] == "!" && ext != "" && !IsMarkdown(target) {
+				continue
+			}
+			if key := linkKey(target); key != "" && !slices.Contains(links, key) {
+				links = append(links, key)
+			}
+		}
+	}
+	return links
+}
+
+// linkKey normalizes a link target or note name the way Obsidian matches
+// them: case-insensitively and without the .md extension.
+func linkKey(target string) string {
+	target = strings.Trim(strings.TrimSpace(target), "/")
+	if IsMarkdown(target) {
+		target = strings.TrimSuffix(target, path.Ext(target))
+	}
+	return strings.ToLower(target)
+}
+
+// inlineTags returns the #tags written in the body outside code blocks.
+// Headings are not tags: they need a space after the #, which the tag
+// pattern does not allow.
+func inlineTags(lines []line) []string {
+	var tags []string
+	for _, l := range lines {
+		if l.inFence {
+			continue
+		}
+		for _, m := range inlineTagPattern.FindAllStringSubmatch(l.text, -1) {
+			tags = append(tags, m[1])
+		}
+	}
+	return tags
+}
+
+// stringList reads a frontmatter value that may be a list or a single
+// string of comma or space separated items, as Obsidian accepts both.
+func stringList(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	case []any:
+		var items []string
+		for _, item := range v {
+			if item != nil {
+				items = append(items, fmt.Sprint(item))
+			}
+		}
+		return items
+	}
+	return nil
+}
+
+// jsonValue converts a decoded YAML value into one encoding/json can
+// marshal: maps with non-string keys get string keys, dates are written the
+// way they appeared, and floats JSON cannot represent become strings.
+func jsonValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = jsonValue(item)
+		}
+		return out
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = jsonValue(item)
+		}
+		return out
+	case time.Time:
+		if v.Equal(v.Truncate(24 * time.Hour)) {
+			return v.Format(time.DateOnly)
+		}
+		return v.Format(time.RFC3339)
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return fmt.Sprint(v)
+		}
+		return v
+	case string, bool, int, int64, uint64, nil:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
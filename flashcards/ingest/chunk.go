@@ -0,0 +1,176 @@
+package ingest
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultChunkSize is the length, in characters, passages are cut to when
+	// the client does not choose one: a few paragraphs, about a page.
+	DefaultChunkSize = 1500
+	MinChunkSize     = 200
+	MaxChunkSize     = 8000
+)
+
+// Chunk is one passage of a document.
+type Chunk struct {
+	Text string
+	// Heading is the heading path of the section the passage is from.
+	Heading []string
+	// FirstPage and LastPage are the PDF pages the passage spans, or 0 for
+	// HTML.
+	FirstPage int
+	LastPage  int
+}
+
+// piece is a paragraph, or a part of one too long for a chunk; joined
+// pieces are separated by sep.
+type piece struct {
+	text string
+	sep  string
+	page int
+}
+
+// Split cuts a document into passages of at most size characters. Passages
+// end at paragraph breaks where possible, then at sentence ends, and only
+// split words that are longer than size. A passage never spans two
+// headings, but may span PDF pages.
+func Split(doc *Document, size int) []Chunk {
+	if size < MinChunkSize || size > MaxChunkSize {
+		size = DefaultChunkSize
+	}
+
+	var (
+		chunks  []Chunk
+		current *Chunk
+		text    strings.Builder
+		length  int
+	)
+	flush := func() {
+		if current != nil && length > 0 {
+			current.Text = text.String()
+			chunks = append(chunks, *current)
+		}
+		current, length = nil, 0
+		text.Reset()
+	}
+
+	for _, section := range doc.Sections {
+		if current != nil && !sameHeading(current.Heading, section.Heading) {
+			flush()
+		}
+		for _, p := range pieces(section, size) {
+			n := utf8.RuneCountInString(p.text)
+			if current != nil && length+len(p.sep)+n > size {
+				flush()
+			}
+			if current == nil {
+				current = &Chunk{Heading: section.Heading, FirstPage: p.page}
+			} else {
+				text.WriteString(p.sep)
+				length += len(p.sep)
+			}
+			text.WriteString(p.text)
+			length += n
+			current.LastPage = p.page
+		}
+	}
+	flush()
+	return chunks
+}
+
+// pieces splits the paragraphs of a section into pieces of at most size
+// characters.
+func pieces(section Section, size int) []piece {
+	var result []piece
+	for _, paragraph := range section.Paragraphs {
+		sep := "\n\n"
+		for _, sentence := range fitSentences(paragraph, size) {
+			result = append(result, piece{text: sentence, sep: sep, page: section.Page})
+			sep = " "
+		}
+	}
+	return result
+}
+
+// fitSentences returns text whole if it fits in size characters, and
+// otherwise its sentences, with sentences that are still too long cut
+// between words.
+func fitSentences(text string, size int) []string {
+	if utf8.RuneCountInString(text) <= size {
+		return []string{text}
+	}
+	var result []string
+	for _, sentence := range sentences(text) {
+		for utf8.RuneCountInString(sentence) > size {
+			cut := cutAt(sentence, size)
+			result = append(result, strings.TrimSpace(sentence[:cut]))
+			sentence = strings.TrimSpace(sentence[cut:])
+		}
+		if sentence != "" {
+			result = append(result, sentence)
+		}
+	}
+	return result
+}
+
+// sentences splits text after each ., ! or ? (and any closing quotes or
+// brackets) that is followed by a space.
+func sentences(text string) []string {
+	var result []string
+	start := 0
+	for i, r := range text {
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		end := i + 1
+		for end < len(text) {
+			next, n := utf8.DecodeRuneInString(text[end:])
+			if !strings.ContainsRune(`"')]’”`, next) {
+				break
+			}
+			end += n
+		}
+		if next, _ := utf8.DecodeRuneInString(text[end:]); unicode.IsSpace(next) {
+			if sentence := strings.TrimSpace(text[start:end]); sentence != "" {
+				result = append(result, sentence)
+			}
+			start = end
+		}
+	}
+	if rest := strings.TrimSpace(text[start:]); rest != "" {
+		result = append(result, rest)
+	}
+	return result
+}
+
+// cutAt returns the byte offset to cut s at so that the first part has at
+// most size characters, preferring the last space.
+func cutAt(s string, size int) int {
+	end, count := len(s), 0
+	for i := range s {
+		if count == size {
+			end = i
+			break
+		}
+		count++
+	}
+	if space := strings.LastIndexFunc(s[:end], unicode.IsSpace); space > 0 {
+		return space
+	}
+	return end
+}
+
+func sameHeading(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
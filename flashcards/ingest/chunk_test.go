@@ -0,0 +1,114 @@
+package ingest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	para := func(c string) string { return strings.Repeat(c, 60) }
+	sentence := func(c string) string { return strings.Repeat(c, 79) + "." }
+	heading := []string{"Cells"}
+
+	tests := []struct {
+		name     string
+		sections []Section
+		size     int
+		want     []Chunk
+	}{
+		{
+			name:     "paragraphs share a chunk while they fit",
+			sections: []Section{{Heading: heading, Paragraphs: []string{para("a"), para("b"), para("c"), para("d")}}},
+			size:     200,
+			want: []Chunk{
+				{Text: para("a") + "\n\n" + para("b") + "\n\n" + para("c"), Heading: heading},
+				{Text: para("d"), Heading: heading},
+			},
+		},
+		{
+			name:     "long paragraphs break between sentences",
+			sections: []Section{{Paragraphs: []string{sentence("a") + " " + sentence("b") + " " + sentence("c")}}},
+			size:     200,
+			want: []Chunk{
+				{Text: sentence("a") + " " + sentence("b")},
+				{Text: sentence("c")},
+			},
+		},
+		{
+			name:     "long sentences break between words",
+			sections: []Section{{Paragraphs: []string{strings.Repeat("word ", 50)}}},
+			size:     200,
+			want: []Chunk{
+				{Text: strings.TrimSpace(strings.Repeat("word ", 39) + "word")},
+				{Text: strings.TrimSpace(strings.Repeat("word ", 10))},
+			},
+		},
+		{
+			name:     "long words are cut",
+			sections: []Section{{Paragraphs: []string{strings.Repeat("x", 450)}}},
+			size:     200,
+			want: []Chunk{
+				{Text: strings.Repeat("x", 200)},
+				{Text: strings.Repeat("x", 200)},
+				{Text: strings.Repeat("x", 50)},
+			},
+		},
+		{
+			name: "a heading starts a new chunk",
+			sections: []Section{
+				{Heading: []string{"Cells", "Mitosis"}, Paragraphs: []string{"One becomes two."}},
+				{Heading: []string{"Cells", "Meiosis"}, Paragraphs: []string{"One becomes four."}},
+			},
+			size: 200,
+			want: []Chunk{
+				{Text: "One becomes two.", Heading: []string{"Cells", "Mitosis"}},
+				{Text: "One becomes four.", Heading: []string{"Cells", "Meiosis"}},
+			},
+		},
+		{
+			name: "chunks span pages",
+			sections: []Section{
+				{Page: 3, Paragraphs: []string{"End of page three."}},
+				{Page: 4, Paragraphs: []string{"Start of page four."}},
+			},
+			size: 200,
+			want: []Chunk{{Text: "End of page three.\n\nStart of page four.", FirstPage: 3, LastPage: 4}},
+		},
+		{
+			name:     "sizes out of range use the default",
+			sections: []Section{{Paragraphs: []string{strings.Repeat("x", 1500), "y"}}},
+			size:     50,
+			want:     []Chunk{{Text: strings.Repeat("x", 1500)}, {Text: "y"}},
+		},
+		{
+			name: "empty document",
+			size: 200,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Split(&Document{Sections: tt.sections}, tt.size)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split() = %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReflow(t *testing.T) {
+	tests := []struct {
+		name, text, want string
+	}{
+		{name: "lines join", text: "The cell\ndivides.", want: "The cell divides."},
+		{name: "hyphenated words join", text: "mito-\nchondria", want: "mitochondria"},
+		{name: "spaces collapse", text: "  two   spaces \n", want: "two spaces"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reflow(tt.text); got != tt.want {
+				t.Errorf("reflow(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
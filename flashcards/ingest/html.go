@@ -0,0 +1,204 @@
+package ingest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// skipped are elements whose text is not part of the document's content.
+var skipped = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true,
+	atom.Template: true, atom.Nav: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Button: true, atom.Svg: true, atom.Iframe: true,
+	atom.Select: true, atom.Textarea: true,
+}
+
+// blocks are elements that start and end a paragraph.
+var blocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true,
+	atom.Main: true, atom.Header: true, atom.Blockquote: true, atom.Pre: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Dl: true, atom.Dt: true,
+	atom.Dd: true, atom.Table: true, atom.Tr: true, atom.Figure: true,
+	atom.Figcaption: true, atom.Hr: true, atom.Details: true, atom.Summary: true,
+	atom.Address: true,
+}
+
+var headingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+// ExtractHTML reads the text of an HTML page, as one section per heading.
+// Only the page's <main> element, or else its first <article>, is read when
+// it has one, and navigation, scripts, forms and the like never are. The
+// character set comes from the page itself, then contentType, then a guess.
+func ExtractHTML(data []byte, contentType string) (*Document, error) {
+	reader, err := charset.NewReader(bytes.NewReader(data), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode HTML: %w", err)
+	}
+	root, err := html.Parse(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	doc := &Document{Kind: KindHTML}
+	if title := find(root, atom.Title); title != nil {
+		doc.Title = collapse(textOf(title))
+	}
+	content := find(root, atom.Main)
+	if content == nil {
+		content = find(root, atom.Article)
+	}
+	if content == nil {
+		content = root
+	}
+
+	w := &htmlWalker{doc: doc}
+	w.walk(content)
+	w.endSection()
+	if doc.Title == "" {
+		if h1 := find(root, atom.H1); h1 != nil {
+			doc.Title = collapse(textOf(h1))
+		}
+	}
+	return doc, nil
+}
+
+// htmlWalker collects the paragraphs of a page into sections.
+type htmlWalker struct {
+	doc      *Document
+	headings []string
+	levels   []int
+	section  []string
+	text     strings.Builder
+	pre      int
+}
+
+func (w *htmlWalker) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if w.pre > 0 {
+			w.text.WriteString(n.Data)
+		} else {
+			// Line breaks in the source are just spaces; only <br> breaks lines.
+			w.text.WriteString(strings.NewReplacer("\n", " ", "\r", " ", "\t", " ").Replace(n.Data))
+		}
+		return
+	case html.ElementNode:
+		if skipped[n.DataAtom] {
+			return
+		}
+		if level, ok := headingLevels[n.DataAtom]; ok {
+			w.heading(level, collapse(textOf(n)))
+			return
+		}
+		if n.DataAtom == atom.Br {
+			w.text.WriteByte('\n')
+			return
+		}
+	}
+
+	block := n.Type == html.ElementNode && blocks[n.DataAtom]
+	if block {
+		w.endParagraph()
+		if n.DataAtom == atom.Li {
+			w.text.WriteString("- ")
+		}
+		if n.DataAtom == atom.Pre {
+			w.pre++
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		w.walk(child)
+	}
+	if block {
+		w.endParagraph()
+		if n.DataAtom == atom.Pre {
+			w.pre--
+		}
+	}
+}
+
+// heading starts a new section under a heading of the given level.
+func (w *htmlWalker) heading(level int, text string) {
+	w.endSection()
+	for len(w.levels) > 0 && w.levels[len(w.levels)-1] >= level {
+		w.levels = w.levels[:len(w.levels)-1]
+		w.headings = w.headings[:len(w.headings)-1]
+	}
+	if text != "" {
+		w.levels = append(w.levels, level)
+		w.headings = append(w.headings, text)
+	}
+}
+
+func (w *htmlWalker) endParagraph() {
+	text := w.text.String()
+	w.text.Reset()
+	if w.pre > 0 {
+		text = strings.Trim(text, "\n")
+	} else {
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			lines[i] = collapse(line)
+		}
+		text = strings.Trim(strings.Join(lines, "\n"), "\n")
+	}
+	if strings.TrimSpace(text) == "" || text == "-" {
+		return
+	}
+	// Items of a list stay together, one per line.
+	if last := len(w.section) - 1; last >= 0 && strings.HasPrefix(text, "- ") && strings.HasPrefix(w.section[last], "- ") {
+		w.section[last] += "\n" + text
+		return
+	}
+	w.section = append(w.section, text)
+}
+
+func (w *htmlWalker) endSection() {
+	w.endParagraph()
+	if len(w.section) > 0 {
+		w.doc.Sections = append(w.doc.Sections, Section{
+			Heading:    append([]string(nil), w.headings...),
+			Paragraphs: w.section,
+		})
+	}
+	w.section = nil
+}
+
+// find returns the first element of type a under n, depth first.
+func find(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := find(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func textOf(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && skipped[child.DataAtom] {
+			continue
+		}
+		b.WriteString(textOf(child))
+	}
+	return b.String()
+}
+
+// collapse turns every run of whitespace into one space and trims the ends.
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
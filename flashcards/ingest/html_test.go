@@ -0,0 +1,117 @@
+package ingest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractHTML(t *testing.T) {
+	tests := []struct {
+		name        string
+		html        string
+		contentType string
+		title       string
+		sections    []Section
+	}{
+		{
+			name: "headings nest into sections",
+			html: `<html><head><title>Biology</title></head><body>
+				<h1>Cells</h1><p>Cells are the unit of life.</p>
+				<h2>Mitosis</h2><p>One cell
+				becomes two.</p>
+				<h2>Meiosis</h2><p>Four cells.</p>
+				<h1>Tissues</h1><p>Groups of cells.</p>
+			</body></html>`,
+			title: "Biology",
+			sections: []Section{
+				{Heading: []string{"Cells"}, Paragraphs: []string{"Cells are the unit of life."}},
+				{Heading: []string{"Cells", "Mitosis"}, Paragraphs: []string{"One cell becomes two."}},
+				{Heading: []string{"Cells", "Meiosis"}, Paragraphs: []string{"Four cells."}},
+				{Heading: []string{"Tissues"}, Paragraphs: []string{"Groups of cells."}},
+			},
+		},
+		{
+			name: "only main is read",
+			html: `<body><nav><p>Home | About</p></nav>
+				<main><p>The content.</p><script>track()</script><form><p>Subscribe</p></form></main>
+				<footer><p>Copyright</p></footer></body>`,
+			sections: []Section{{Paragraphs: []string{"The content."}}},
+		},
+		{
+			name:     "first article without main",
+			html:     `<body><aside><p>Related</p></aside><article><h1>Post</h1><p>Body.</p></article><article><p>Other.</p></article></body>`,
+			title:    "Post",
+			sections: []Section{{Heading: []string{"Post"}, Paragraphs: []string{"Body."}}},
+		},
+		{
+			name: "list items stay together, br breaks lines",
+			html: `<body><p>Steps:</p><ul><li>Prophase</li><li>Metaphase</li></ul><p>Line one<br>line two</p></body>`,
+			sections: []Section{{Paragraphs: []string{
+				"Steps:", "- Prophase\n- Metaphase", "Line one\nline two",
+			}}},
+		},
+		{
+			name:     "pre keeps its whitespace",
+			html:     "<body><pre>\nfunc main() {\n\tfmt.Println()\n}\n</pre></body>",
+			sections: []Section{{Paragraphs: []string{"func main() {\n\tfmt.Println()\n}"}}},
+		},
+		{
+			name:        "charset from Content-Type",
+			html:        "<body><p>Caf\xe9 cr\xe8me</p></body>",
+			contentType: "text/html; charset=windows-1252",
+			sections:    []Section{{Paragraphs: []string{"Café crème"}}},
+		},
+		{
+			name:     "charset from meta",
+			html:     "<head><meta charset=\"iso-8859-1\"></head><body><p>Troms\xf8</p></body>",
+			sections: []Section{{Paragraphs: []string{"Tromsø"}}},
+		},
+		{
+			name: "empty page",
+			html: `<body><script>x()</script></body>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ExtractHTML([]byte(tt.html), tt.contentType)
+			if err != nil {
+				t.Fatalf("ExtractHTML: %v", err)
+			}
+			if doc.Kind != KindHTML || doc.Title != tt.title {
+				t.Errorf("kind %q, title %q; want html, %q", doc.Kind, doc.Title, tt.title)
+			}
+			if !reflect.DeepEqual(doc.Sections, tt.sections) {
+				t.Errorf("sections = %#v\nwant %#v", doc.Sections, tt.sections)
+			}
+		})
+	}
+}
+
+func TestDetectKind(t *testing.T) {
+	tests := []struct {
+		name, file, contentType string
+		data                    string
+		want                    Kind
+	}{
+		{name: "Content-Type wins", file: "notes.html", contentType: "application/pdf", want: KindPDF},
+		{name: "xhtml type", file: "upload", contentType: "application/xhtml+xml", want: KindHTML},
+		{name: "extension", file: "Paper.PDF", contentType: "application/octet-stream", want: KindPDF},
+		{name: "sniffed PDF", file: "upload", data: "%PDF-1.7\n", want: KindPDF},
+		{name: "sniffed HTML", file: "upload", data: "<!DOCTYPE html><p>hi", want: KindHTML},
+		{name: "unknown", file: "notes.txt", data: "plain text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectKind(tt.file, tt.contentType, []byte(tt.data))
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("DetectKind = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("DetectKind = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
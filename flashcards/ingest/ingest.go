@@ -0,0 +1,88 @@
+// Package ingest extracts the text of PDF and HTML documents and cuts it
+// into passages small enough to become notes. It only reads: turning the
+// passages into notes is up to the importer package, and condensing them up
+// to the note services.
+package ingest
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Kind is a document format that can be ingested.
+type Kind string
+
+const (
+	KindPDF  Kind = "pdf"
+	KindHTML Kind = "html"
+	// KindMarkdown is the content of a stored note, read to cut long notes
+	// into chunks. Uploads are never detected as Markdown.
+	KindMarkdown Kind = "markdown"
+)
+
+// Document is the text of an ingested document.
+type Document struct {
+	// Title is the document's own title, from the PDF metadata or the HTML
+	// <title>, or else empty.
+	Title    string
+	Kind     Kind
+	Sections []Section
+	// Pages is the number of pages of a PDF, and 0 for HTML.
+	Pages int
+}
+
+// Section is text from one place in a document: a page of a PDF, or the
+// text under one heading of an HTML page.
+type Section struct {
+	// Page is the PDF page the text is on, counting from 1, or 0 for HTML.
+	Page int
+	// Heading is the path of headings the text is under, outermost first,
+	// e.g. ["Cells", "Mitosis"].
+	Heading    []string
+	Paragraphs []string
+}
+
+// DetectKind works out the format of a document from its Content-Type, its
+// file name and finally its first bytes.
+func DetectKind(name, contentType string, data []byte) (Kind, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if kind, ok := kindOf(mediaType); ok {
+		return kind, nil
+	}
+	switch strings.ToLower(path.Ext(name)) {
+	case ".pdf":
+		return KindPDF, nil
+	case ".html", ".htm", ".xhtml":
+		return KindHTML, nil
+	}
+	mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+	if kind, ok := kindOf(mediaType); ok {
+		return kind, nil
+	}
+	return "", fmt.Errorf("%s is not a PDF or HTML document", name)
+}
+
+func kindOf(mediaType string) (Kind, bool) {
+	switch mediaType {
+	case "application/pdf":
+		return KindPDF, true
+	case "text/html", "application/xhtml+xml":
+		return KindHTML, true
+	}
+	return "", false
+}
+
+// Extract reads the text of a document. contentType is only used to find
+// the character set of HTML that does not declare one.
+func Extract(kind Kind, contentType string, data []byte) (*Document, error) {
+	switch kind {
+	case KindPDF:
+		return ExtractPDF(data)
+	case KindHTML:
+		return ExtractHTML(data, contentType)
+	}
+	return nil, fmt.Errorf("unsupported document kind %q", kind)
+}
@@ -0,0 +1,83 @@
+package ingest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// MaxPages caps how many pages of a PDF are read.
+const MaxPages = 2000
+
+// ExtractPDF reads the text of each page of a PDF. Lines are joined back
+// into running text, since PDFs place text line by line and keep no
+// paragraphs; words hyphenated across lines are rejoined. Scanned pages
+// have no text and are left out.
+func ExtractPDF(data []byte) (doc *Document, err error) {
+	// The PDF reader panics on some malformed files.
+	defer func() {
+		if r := recover(); r != nil {
+			doc, err = nil, fmt.Errorf("failed to read PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+
+	doc = &Document{Kind: KindPDF, Pages: reader.NumPage()}
+	if doc.Pages > MaxPages {
+		return nil, fmt.Errorf("PDF has %d pages, more than the %d page limit", doc.Pages, MaxPages)
+	}
+	doc.Title = strings.TrimSpace(reader.Trailer().Key("Info").Key("Title").Text())
+
+	fonts := make(map[string]*pdf.Font)
+	for i := 1; i <= doc.Pages; i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		for _, name := range page.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				font := page.Font(name)
+				fonts[name] = &font
+			}
+		}
+		text, err := page.GetPlainText(fonts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read page %d of PDF: %w", i, err)
+		}
+		if text = reflow(text); text != "" {
+			doc.Sections = append(doc.Sections, Section{Page: i, Paragraphs: []string{text}})
+		}
+	}
+	return doc, nil
+}
+
+// reflow joins the lines of a page into one run of text.
+func reflow(text string) string {
+	var b strings.Builder
+	hyphenated := false
+	for line := range strings.Lines(text) {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			continue
+		}
+		if b.Len() > 0 && !hyphenated {
+			b.WriteByte(' ')
+		}
+		// A word hyphenated at the end of a line continues on the next.
+		hyphenated = len(line) > 1 && strings.HasSuffix(line, "-") && !strings.HasSuffix(line, " -")
+		if hyphenated {
+			line = line[:len(line)-1]
+		}
+		b.WriteString(line)
+	}
+	if hyphenated {
+		b.WriteByte('-')
+	}
+	return b.String()
+}
@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "flashcards"
+
+// The default registry already carries the Go runtime and process collectors,
+// so everything registered here is exposed alongside them on /metrics.
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests by route template, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	dbQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of repository calls by repository, method and outcome.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repository", "method", "outcome"})
+
+	llmRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "llm",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of LLM calls by operation and outcome.",
+		Buckets:   []float64{.25, .5, 1, 2, 4, 8, 16, 32, 64},
+	}, []string{"operation", "outcome"})
+
+	llmTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "llm",
+		Name:      "tokens_total",
+		Help:      "Tokens consumed by LLM calls, split into prompt and completion tokens.",
+	}, []string{"operation", "type"})
+
+	llmErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "llm",
+		Name:      "errors_total",
+		Help:      "LLM calls that failed or returned no usable content.",
+	}, []string{"operation", "reason"})
+)
+
+// Handler serves the registered metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterDBStats exposes the connection pool statistics of db (open, idle
+// and in-use connections, wait counts and durations) as gauges and counters.
+func RegisterDBStats(db *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, "postgres"))
+}
+
+// Middleware records the duration of every request matched by the router,
+// labelled with the mux route template rather than the raw path so that IDs
+// do not explode the label cardinality.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		httpRequestDuration.
+			WithLabelValues(route, r.Method, strconv.Itoa(recorder.status)).
+			Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveDBQuery records how long a repository method took and whether it failed.
+func ObserveDBQuery(repository, method string, start time.Time, err error) {
+	dbQueryDuration.
+		WithLabelValues(repository, method, outcome(err)).
+		Observe(time.Since(start).Seconds())
+}
+
+// ObserveLLMCall records the latency and outcome of a single LLM call.
+func ObserveLLMCall(operation string, start time.Time, err error) {
+	llmRequestDuration.
+		WithLabelValues(operation, outcome(err)).
+		Observe(time.Since(start).Seconds())
+	if err != nil {
+		llmErrors.WithLabelValues(operation, "request_failed").Inc()
+	}
+}
+
+// ObserveLLMTokens adds the prompt and completion token counts reported by the provider.
+func ObserveLLMTokens(operation string, promptTokens, completionTokens int) {
+	llmTokens.WithLabelValues(operation, "prompt").Add(float64(promptTokens))
+	llmTokens.WithLabelValues(operation, "completion").Add(float64(completionTokens))
+}
+
+// ObserveLLMEmptyResponse counts calls that succeeded but produced no content.
+func ObserveLLMEmptyResponse(operation string) {
+	llmErrors.WithLabelValues(operation, "empty_response").Inc()
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer (e.g. for flushing).
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
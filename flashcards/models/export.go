@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ExportVersion is the version of the Export document written by the
+// export endpoint. Imports reject other versions.
+const ExportVersion = 1
+
+// Export is every note, note link and todo, as written by the JSON export
+// and read back by the import endpoint. IDs and timestamps are kept, so an
+// export restored into an empty database reproduces it exactly.
+type Export struct {
+	Version    int        `json:"version"`
+	ExportedAt time.Time  `json:"exported_at"`
+	Notes      []*Note    `json:"notes"`
+	NoteLinks  []NoteLink `json:"note_links"`
+	Todos      []*Todo    `json:"todos"`
+}
+
+// RestoreResult counts what an import of an Export wrote.
+type RestoreResult struct {
+	Notes     int `json:"notes"`
+	NoteLinks int `json:"note_links"`
+	Todos     int `json:"todos"`
+}
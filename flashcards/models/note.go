@@ -3,16 +3,52 @@ package models
 import "time"
 
 type Note struct {
-	ID        int       `json:"id" db:"id"`
-	Content   string    `json:"content" db:"content"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID         int            `json:"id" db:"id"`
+	Title      string         `json:"title" db:"title"`
+	Content    string         `json:"content" db:"content"`
+	Tags       []string       `json:"tags" db:"tags"`
+	Metadata   map[string]any `json:"metadata" db:"metadata"`
+	SourcePath string         `json:"source_path,omitempty" db:"source_path"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at" db:"updated_at"`
+	// DeletedAt is set on notes soft-deleted because their source file was
+	// removed. Such notes are hidden from every read except
+	// GetNoteBySourcePath, so importing the file again restores them.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// NoteLink is a link from one note to another.
+type NoteLink struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// NoteLinks lists the notes a note links to and the notes linking to it.
+type NoteLinks struct {
+	NoteID   int   `json:"note_id"`
+	Outgoing []int `json:"outgoing"`
+	Incoming []int `json:"incoming"`
+}
+
+// NoteChunk is one passage of a note's content. Long notes are stored as
+// several ordered chunks so the quiz can work on focused passages; the note
+// itself still holds the whole content.
+type NoteChunk struct {
+	NoteID   int `json:"note_id"`
+	Position int `json:"position"`
+	// Heading is the path of Markdown headings the passage is under.
+	Heading []string `json:"heading"`
+	Content string   `json:"content"`
 }
 
 type CreateNoteRequest struct {
-	Content string `json:"content"`
+	Title   string   `json:"title,omitempty"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags,omitempty"`
 }
 
 type UpdateNoteRequest struct {
-	Content *string `json:"content,omitempty"` // Why did tutorial's Claude Code use a pointer?
+	Title   *string   `json:"title,omitempty"`
+	Content *string   `json:"content,omitempty"` // Why did tutorial's Claude Code use a pointer?
+	Tags    *[]string `json:"tags,omitempty"`
 }
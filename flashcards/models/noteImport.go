@@ -0,0 +1,83 @@
+package models
+
+// ImportStatus is the outcome for one item of a bulk import.
+type ImportStatus string
+
+const (
+	ImportCreated ImportStatus = "created"
+	ImportUpdated ImportStatus = "updated"
+	ImportFailed  ImportStatus = "failed"
+)
+
+// ImportItemResult reports what happened to one note found in an upload.
+// Source identifies where it came from, e.g. "biology/cells.md#2".
+type ImportItemResult struct {
+	Source string       `json:"source"`
+	Title  string       `json:"title,omitempty"`
+	Status ImportStatus `json:"status"`
+	NoteID int          `json:"note_id,omitempty"`
+	Error  string       `json:"error,omitempty"`
+	// UnresolvedLinks are link targets that matched no note in the import.
+	UnresolvedLinks []string `json:"unresolved_links,omitempty"`
+	// Content and Tags show the note that would be stored, in dry runs.
+	Content string   `json:"content,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// ImportResult summarises a bulk import.
+type ImportResult struct {
+	Created int                `json:"created"`
+	Updated int                `json:"updated"`
+	Failed  int                `json:"failed"`
+	Items   []ImportItemResult `json:"items"`
+	// DryRun is set when nothing was stored: the counts and statuses say
+	// what an import would do.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// CardFileFormat reports how a card file was read.
+type CardFileFormat struct {
+	Source    string   `json:"source"`
+	Delimiter string   `json:"delimiter"`
+	Encoding  string   `json:"encoding"`
+	Header    []string `json:"header,omitempty"`
+	Rows      int      `json:"rows"`
+}
+
+// CardImportResult is an ImportResult for card files, with the format each
+// file was read in.
+type CardImportResult struct {
+	ImportResult
+	Files []CardFileFormat `json:"files"`
+}
+
+// Add records an item result and updates the counts.
+func (r *ImportResult) Add(item ImportItemResult) {
+	switch item.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	case ImportFailed:
+		r.Failed++
+	}
+	r.Items = append(r.Items, item)
+}
+
+// IngestedDocument describes a document ingested into notes.
+type IngestedDocument struct {
+	Name  string `json:"name"`
+	Title string `json:"title,omitempty"`
+	Type  string `json:"type"`
+	// Pages is the page count of a PDF.
+	Pages     int `json:"pages,omitempty"`
+	Chunks    int `json:"chunks"`
+	Condensed int `json:"condensed"`
+}
+
+// IngestResult is an ImportResult for an ingested document, with what was
+// found in it.
+type IngestResult struct {
+	ImportResult
+	Document IngestedDocument `json:"document"`
+}
@@ -0,0 +1,48 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+const docsPage = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>Flashcards API</title>
+    <meta charset="utf-8"/>
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+  </head>
+  <body>
+    <redoc spec-url="openapi.json"></redoc>
+    <script src="https://cdn.redoc.ly/redoc/latest/bundles/redoc.standalone.js"></script>
+  </body>
+</html>
+`
+
+// Spec returns the raw OpenAPI 3 document.
+func Spec() []byte {
+	return spec
+}
+
+// RegisterRoutes serves the document at /openapi.json and a Redoc UI at /docs.
+func RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/openapi.json", serveSpec).Methods("GET")
+	router.HandleFunc("/docs", serveDocs).Methods("GET")
+}
+
+func serveSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(spec)
+}
+
+func serveDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(docsPage))
+}
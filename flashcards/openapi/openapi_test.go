@@ -0,0 +1,114 @@
+package openapi_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"go-ai-eng-flashcards/config"
+	"go-ai-eng-flashcards/db"
+	"go-ai-eng-flashcards/handlers"
+	"go-ai-eng-flashcards/openapi"
+	"go-ai-eng-flashcards/server"
+
+	"github.com/gorilla/mux"
+)
+
+// pathVariablePattern matches mux path variables with an optional regexp,
+// e.g. {id:[0-9]+}, so they can be compared with OpenAPI's {id}.
+var pathVariablePattern = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// routes returns "METHOD /path" for every route registered on router.
+func routes(t *testing.T, router *mux.Router) []string {
+	t.Helper()
+	var found []string
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			// Subrouters without a path of their own have nothing to document.
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		path := pathVariablePattern.ReplaceAllString(template, "{$1}")
+		for _, method := range methods {
+			found = append(found, method+" "+path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to walk routes: %v", err)
+	}
+	return found
+}
+
+// TestSpecCoversRoutes fails when a route the server registers is missing
+// from openapi.json, or the document describes one no route serves.
+func TestSpecCoversRoutes(t *testing.T) {
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openapi.Spec(), &doc); err != nil {
+		t.Fatalf("failed to parse OpenAPI document: %v", err)
+	}
+	// Path items also hold shared fields such as parameters; only the
+	// HTTP methods are operations.
+	methods := []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+	var documented []string
+	for path, item := range doc.Paths {
+		for key := range item {
+			if slices.Contains(methods, key) {
+				documented = append(documented, strings.ToUpper(key)+" "+path)
+			}
+		}
+	}
+
+	cfg := config.Default()
+	cfg.GeminiAPIKey = "test-key"
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store, err := db.OpenStore(context.Background(), db.DriverMemory, "", db.PoolConfig{}, logger)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+	srv, err := server.New(cfg, store, "test", logger)
+	if err != nil {
+		t.Fatalf("failed to build server: %v", err)
+	}
+	registered := routes(t, srv.Router)
+
+	// /debug/db is only served on a Postgres pool with DEBUG_ENDPOINTS set.
+	health := handlers.NewHealthHandler("test", logger)
+	health.SetPoolStats(func() sql.DBStats { return sql.DBStats{} })
+	debug := mux.NewRouter()
+	health.RegisterRoutes(debug)
+	registered = append(registered, routes(t, debug)...)
+
+	var missing, stale []string
+	for _, route := range registered {
+		if !slices.Contains(documented, route) {
+			missing = append(missing, route)
+		}
+	}
+	for _, operation := range documented {
+		if !slices.Contains(registered, operation) {
+			stale = append(stale, operation)
+		}
+	}
+	slices.Sort(missing)
+	slices.Sort(stale)
+	if len(missing) > 0 {
+		t.Errorf("routes missing from the OpenAPI document: %s", strings.Join(missing, ", "))
+	}
+	if len(stale) > 0 {
+		t.Errorf("documented operations no route serves: %s", strings.Join(stale, ", "))
+	}
+}
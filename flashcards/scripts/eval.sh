@@ -19,9 +19,9 @@ call_quiz_api() {
   echo "" >> "$OUTPUT_FILE"
   echo "API Output:" >> "$OUTPUT_FILE"
 
-  curl -s -X POST http://localhost:8080/quiz \
-  -H "Content-Type: application/json" \
-  -d "$payload" | python -m json.tool >> "$OUTPUT_FILE"
+  curl -s -X POST http://localhost:8080/api/v1/quiz \
+  -H "Content-Type: application/json; charset=utf-8" \
+  --data-binary "$payload" | PYTHONIOENCODING=utf-8 python -m json.tool --no-ensure-ascii >> "$OUTPUT_FILE"
 
   echo "" >> "$OUTPUT_FILE"
   echo "--------------------------------------------------" >> "$OUTPUT_FILE"
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"go-ai-eng-flashcards/anki"
+	"go-ai-eng-flashcards/models"
+)
+
+func TestAnkiExportImportRoundTrip(t *testing.T) {
+	handler := newTestHandler(t, nil)
+
+	// One note first imported from Anki and one written here.
+	var deck bytes.Buffer
+	err := anki.WritePackage(&deck, []anki.Note{{
+		GUID:     "f7Q+ab1~Zx",
+		NoteType: "Basic",
+		Kind:     anki.KindStandard,
+		Deck:     "History::Vikings",
+		Fields:   []anki.Field{{Name: "Front", Value: "Who wrote the Prose Edda?"}, {Name: "Back", Value: "Snorri Sturluson"}},
+		Modified: time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := serve(t, handler, http.MethodPost, "/api/v1/notes/import/anki?filename=vikings.apkg", "application/octet-stream", deck.Bytes())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("import status = %d, body %s", rec.Code, rec.Body)
+	}
+	rec = serve(t, handler, http.MethodPost, "/api/v1/notes", "application/json", []byte(`{"title": "Yggdrasil", "content": "The world tree.", "tags": ["norse"]}`))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, body %s", rec.Code, rec.Body)
+	}
+	var native models.Note
+	if err := json.Unmarshal(rec.Body.Bytes(), &native); err != nil {
+		t.Fatal(err)
+	}
+
+	rec = serve(t, handler, http.MethodGet, "/api/v1/notes/export/anki", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export status = %d, body %s", rec.Code, rec.Body)
+	}
+	exported := rec.Body.Bytes()
+
+	for i := range 2 {
+		rec = serve(t, handler, http.MethodPost, "/api/v1/notes/import/anki?filename=export.apkg", "application/octet-stream", exported)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("re-import %d status = %d, body %s", i+1, rec.Code, rec.Body)
+		}
+		var result models.ImportResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		if result.Created != 0 || result.Updated != 2 {
+			t.Fatalf("re-import %d created %d and updated %d notes, want 0 and 2", i+1, result.Created, result.Updated)
+		}
+
+		rec = serve(t, handler, http.MethodGet, "/api/v1/notes", "", nil)
+		var notes []models.Note
+		if err := json.Unmarshal(rec.Body.Bytes(), &notes); err != nil {
+			t.Fatal(err)
+		}
+		if len(notes) != 2 {
+			t.Fatalf("after re-import %d there are %d notes, want 2", i+1, len(notes))
+		}
+		for _, note := range notes {
+			if note.Title == native.Title && note.ID != native.ID {
+				t.Fatalf("re-import %d stored %q as note %d, want note %d", i+1, note.Title, note.ID, native.ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-ai-eng-flashcards/config"
+)
+
+func preflight(t *testing.T, handler http.Handler, origin string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/notes", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	// Browsers send the requested headers lowercased, as the Fetch spec requires.
+	req.Header.Set("Access-Control-Request-Headers", "content-type")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Result()
+}
+
+func TestCORSPreflight(t *testing.T) {
+	cfg := config.Default()
+	cfg.CORSAllowedOrigins = []string{"https://app.example.com", "https://*.preview.example.com"}
+	cfg.CORSAllowCredentials = true
+	handler := newTestHandler(t, cfg)
+
+	tests := []struct {
+		name    string
+		origin  string
+		allowed bool
+	}{
+		{"allowed origin", "https://app.example.com", true},
+		{"wildcard subdomain", "https://pr-42.preview.example.com", true},
+		{"disallowed origin", "https://evil.example.org", false},
+		{"wildcard does not match the bare domain", "https://preview.example.com", false},
+		{"scheme must match", "http://app.example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := preflight(t, handler, tt.origin)
+			got := res.Header.Get("Access-Control-Allow-Origin")
+			if !tt.allowed {
+				if got != "" {
+					t.Fatalf("Access-Control-Allow-Origin = %q, want none", got)
+				}
+				return
+			}
+			if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 204 or 200", res.StatusCode)
+			}
+			if got != tt.origin {
+				t.Fatalf("Access-Control-Allow-Origin = %q, want %q", got, tt.origin)
+			}
+			if res.Header.Get("Access-Control-Allow-Credentials") != "true" {
+				t.Fatal("Access-Control-Allow-Credentials is not set")
+			}
+			if res.Header.Get("Access-Control-Allow-Methods") != http.MethodPost {
+				t.Fatalf("Access-Control-Allow-Methods = %q, want POST", res.Header.Get("Access-Control-Allow-Methods"))
+			}
+			if res.Header.Get("Access-Control-Max-Age") != "600" {
+				t.Fatalf("Access-Control-Max-Age = %q, want 600", res.Header.Get("Access-Control-Max-Age"))
+			}
+		})
+	}
+}
+
+func TestCORSSimpleRequest(t *testing.T) {
+	cfg := config.Default()
+	cfg.CORSAllowedOrigins = []string{"https://app.example.com"}
+	handler := newTestHandler(t, cfg)
+
+	for origin, want := range map[string]string{
+		"https://app.example.com":  "https://app.example.com",
+		"https://evil.example.org": "",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/notes", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200", origin, rec.Code)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != want {
+			t.Fatalf("%s: Access-Control-Allow-Origin = %q, want %q", origin, got, want)
+		}
+	}
+}
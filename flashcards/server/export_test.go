@@ -0,0 +1,137 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go-ai-eng-flashcards/config"
+)
+
+// exportDocument is a restorable export with notes, a link between them and
+// a todo. The linked-to note is soft-deleted, as a watched note is once its
+// file is removed.
+const exportDocument = `{
+	"version": 1,
+	"exported_at": "2026-10-18T12:00:00Z",
+	"notes": [
+		{"id": 3, "title": "Ragnarök", "content": "The twilight of the gods.", "tags": ["norse"], "metadata": {}, "source_path": "ragnarok.md", "created_at": "2026-10-01T09:30:00Z", "updated_at": "2026-10-02T09:30:00Z", "deleted_at": "2026-10-05T09:30:00Z"},
+		{"id": 5, "title": "Yggdrasil", "content": "The world tree.", "tags": [], "metadata": {"source": "edda"}, "source_path": "edda.md", "created_at": "2026-10-03T09:30:00Z", "updated_at": "2026-10-03T09:30:00Z"}
+	],
+	"note_links": [{"from": 5, "to": 3}],
+	"todos": [
+		{"id": 9, "title": "Read Völuspá", "description": "", "completed": true, "createdAt": "2026-10-04T09:30:00Z", "updatedAt": "2026-10-04T09:30:00Z"}
+	]
+}`
+
+// withoutExportTime decodes an export and drops exported_at, which differs
+// between exports of the same data.
+func withoutExportTime(t *testing.T, data []byte) map[string]any {
+	t.Helper()
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("export is not valid JSON: %v\n%s", err, data)
+	}
+	delete(doc, "exported_at")
+	return doc
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	handler := newTestHandler(t, nil)
+
+	rec := serve(t, handler, http.MethodPost, "/api/v1/import", "application/json", []byte(exportDocument))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("import status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	rec = serve(t, handler, http.MethodGet, "/api/v1/export", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export status = %d, body %s", rec.Code, rec.Body)
+	}
+	if disposition := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(disposition, "attachment;") {
+		t.Fatalf("Content-Disposition = %q, want an attachment", disposition)
+	}
+	exported := rec.Body.Bytes()
+
+	// Restoring the export into an empty server reproduces it exactly.
+	restored := newTestHandler(t, nil)
+	if rec := serve(t, restored, http.MethodPost, "/api/v1/import", "application/json", exported); rec.Code != http.StatusOK {
+		t.Fatalf("re-import status = %d, body %s", rec.Code, rec.Body)
+	}
+	again := serve(t, restored, http.MethodGet, "/api/v1/export", "", nil).Body.Bytes()
+
+	first, second := withoutExportTime(t, exported), withoutExportTime(t, again)
+	firstJSON, _ := json.Marshal(first)
+	secondJSON, _ := json.Marshal(second)
+	if string(firstJSON) != string(secondJSON) {
+		t.Fatalf("export after restore = %s, want %s", secondJSON, firstJSON)
+	}
+	if notes := first["notes"].([]any); len(notes) != 2 {
+		t.Fatalf("export has %d notes, want 2", len(notes))
+	}
+	if links := first["note_links"].([]any); len(links) != 1 {
+		t.Fatalf("export has %d note links, want 1", len(links))
+	}
+	deleted := first["notes"].([]any)[1].(map[string]any)
+	if deleted["id"] != float64(3) || deleted["deleted_at"] != "2026-10-05T09:30:00Z" {
+		t.Fatalf("soft-deleted note exported as %v, want id 3 with its deleted_at", deleted)
+	}
+
+	// The soft-deleted note is restored as deleted, not brought back to life.
+	var notes []map[string]any
+	rec = serve(t, restored, http.MethodGet, "/api/v1/notes", "", nil)
+	if err := json.Unmarshal(rec.Body.Bytes(), &notes); err != nil || len(notes) != 1 || notes[0]["id"] != float64(5) {
+		t.Fatalf("notes after restore = %s, want only note 5", rec.Body)
+	}
+}
+
+func TestExportFormats(t *testing.T) {
+	handler := newTestHandler(t, nil)
+	serve(t, handler, http.MethodPost, "/api/v1/import", "application/json", []byte(exportDocument))
+
+	for format, want := range map[string]string{"csv": "2026-10-05T09:30:00Z\n", "md": "\nDeleted Mon, 05 Oct 2026 09:30:00 UTC"} {
+		rec := serve(t, handler, http.MethodGet, "/api/v1/export?format="+format, "", nil)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("%s export = %d %q, want it to contain %q", format, rec.Code, rec.Body, want)
+		}
+	}
+	if rec := serve(t, handler, http.MethodGet, "/api/v1/export?format=xml", "", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("xml export status = %d, want 400", rec.Code)
+	}
+}
+
+func TestImportAppliesContentPolicy(t *testing.T) {
+	cfg := config.Default()
+	cfg.NoteAllowHTML = false
+	handler := newTestHandler(t, cfg)
+
+	// Notes are normalized like created ones: NFC, trimmed, lowercased tags.
+	normalized := strings.Replace(exportDocument, `"Ragnarök"`, `"  Ragnarök  "`, 1)
+	normalized = strings.Replace(normalized, `["norse"]`, `["Norse"]`, 1)
+	if rec := serve(t, handler, http.MethodPost, "/api/v1/import", "application/json", []byte(normalized)); rec.Code != http.StatusOK {
+		t.Fatalf("import status = %d, body %s", rec.Code, rec.Body)
+	}
+	exported := serve(t, handler, http.MethodGet, "/api/v1/export", "", nil).Body.String()
+	if !strings.Contains(exported, `"title":"Ragnarök"`) {
+		t.Fatalf("export after restore = %s, want the note normalized", exported)
+	}
+
+	// A note the policy refuses rejects the whole document.
+	handler = newTestHandler(t, cfg)
+	withHTML := strings.Replace(exportDocument, "The world tree.", "The <script>world</script> tree.", 1)
+	rec := serve(t, handler, http.MethodPost, "/api/v1/import", "application/json", []byte(withHTML))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "note 5") {
+		t.Fatalf("import status = %d, body %s, want 400 naming note 5", rec.Code, rec.Body)
+	}
+	var doc struct {
+		Notes []any `json:"notes"`
+		Todos []any `json:"todos"`
+	}
+	if err := json.Unmarshal(serve(t, handler, http.MethodGet, "/api/v1/export", "", nil).Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Notes) != 0 || len(doc.Todos) != 0 {
+		t.Fatalf("rejected import stored %d notes and %d todos, want none", len(doc.Notes), len(doc.Todos))
+	}
+}
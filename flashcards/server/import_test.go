@@ -0,0 +1,46 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"go-ai-eng-flashcards/importer"
+)
+
+func TestImportRejectsZipBomb(t *testing.T) {
+	// Two small archives whose files are each within importer.MaxFileSize
+	// but together inflate past importer.MaxUploadSize.
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	zeros := make([]byte, importer.MaxFileSize)
+	for i := range importer.MaxUploadSize/importer.MaxFileSize/2 + 1 {
+		w, err := zw.Create(fmt.Sprintf("%d.md", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(zeros)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for _, name := range []string{"a.zip", "b.zip"} {
+		part, err := form.CreateFormFile("files", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(archive.Bytes())
+	}
+	form.Close()
+
+	rec := serve(t, newTestHandler(t, nil), http.MethodPost, "/api/v1/notes/import", form.FormDataContentType(), body.Bytes())
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413; body %s", rec.Code, rec.Body)
+	}
+}
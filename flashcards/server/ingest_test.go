@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-ai-eng-flashcards/ingest"
+	"go-ai-eng-flashcards/models"
+	"go-ai-eng-flashcards/services"
+)
+
+// page returns an HTML page with the given number of paragraphs, each as
+// long as a default chunk, so each becomes one passage.
+func page(paragraphs int) []byte {
+	var b strings.Builder
+	b.WriteString("<html><head><title>Cells</title></head><body>")
+	for range paragraphs {
+		b.WriteString("<p>" + strings.Repeat("x", ingest.DefaultChunkSize) + "</p>")
+	}
+	b.WriteString("</body></html>")
+	return []byte(b.String())
+}
+
+func TestIngestDocument(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		paragraphs int
+		wantStatus int
+		wantNotes  int
+		wantTags   []string
+	}{
+		{
+			name:       "tags are merged and de-duplicated",
+			query:      "?filename=cells.html&tags=biology,%20biology%20,cells,biology",
+			paragraphs: 2,
+			wantStatus: http.StatusOK,
+			wantNotes:  2,
+			wantTags:   []string{"biology", "cells"},
+		},
+		{
+			name:       "too many passages to condense",
+			query:      "?filename=cells.html&condense=true",
+			paragraphs: services.MaxCondensedChunks + 1,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "many passages without condensing",
+			query:      "?filename=cells.html",
+			paragraphs: services.MaxCondensedChunks + 1,
+			wantStatus: http.StatusOK,
+			wantNotes:  services.MaxCondensedChunks + 1,
+			wantTags:   []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestHandler(t, nil)
+			rec := serve(t, handler, http.MethodPost, "/api/v1/notes/ingest"+tt.query, "text/html", page(tt.paragraphs))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+
+			rec = serve(t, handler, http.MethodGet, "/api/v1/notes", "", nil)
+			var notes []models.Note
+			if err := json.Unmarshal(rec.Body.Bytes(), &notes); err != nil {
+				t.Fatalf("notes are not valid JSON: %v", err)
+			}
+			if len(notes) != tt.wantNotes {
+				t.Fatalf("got %d notes, want %d", len(notes), tt.wantNotes)
+			}
+			for _, note := range notes {
+				if !reflect.DeepEqual(note.Tags, tt.wantTags) {
+					t.Errorf("note %q tags = %q, want %q", note.Title, note.Tags, tt.wantTags)
+				}
+			}
+		})
+	}
+}
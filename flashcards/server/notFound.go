@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// notFoundHandler answers requests no route matched. API paths get a JSON
+// 404, or a 405 with an Allow header when the path exists under another
+// method: mux loses that distinction for routes on a subrouter once a later
+// route on the same subrouter fails to match. Everything else goes to the
+// frontend when one is served.
+func notFoundHandler(router *mux.Router, frontend http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		isAPI := r.URL.Path == "/api" || strings.HasPrefix(r.URL.Path, "/api/")
+		if !isAPI && frontend != nil {
+			frontend.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if allowed := allowedMethods(router, r.URL.Path); len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			w.Write([]byte(`{"error":"method not allowed"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not found"}`))
+	})
+}
+
+// allowedMethods lists the methods registered for routes whose path matches path.
+func allowedMethods(router *mux.Router, path string) []string {
+	var allowed []string
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		pattern, err := route.GetPathRegexp()
+		if err != nil {
+			return nil
+		}
+		if matched, _ := regexp.MatchString(pattern, path); matched {
+			for _, method := range methods {
+				if !slices.Contains(allowed, method) {
+					allowed = append(allowed, method)
+				}
+			}
+		}
+		return nil
+	})
+	slices.Sort(allowed)
+	return allowed
+}
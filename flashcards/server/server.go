@@ -0,0 +1,154 @@
+// Package server assembles the API: its services, routes, middleware and
+// CORS policy. cmd/main.go serves it; tests run it on an httptest.Server.
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go-ai-eng-flashcards/apiversion"
+	"go-ai-eng-flashcards/config"
+	"go-ai-eng-flashcards/db"
+	"go-ai-eng-flashcards/handlers"
+	"go-ai-eng-flashcards/metrics"
+	"go-ai-eng-flashcards/openapi"
+	"go-ai-eng-flashcards/services"
+	"go-ai-eng-flashcards/tracing"
+	"go-ai-eng-flashcards/web"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/cors"
+)
+
+// legacyRoutes keeps the paths used before the API was versioned working
+// until the sunset date. Both the original root paths and the short-lived
+// unversioned /api paths map onto v1.
+var legacyRoutes = apiversion.Deprecation{
+	Since:  time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC),
+	Sunset: time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC),
+	Aliases: []apiversion.Alias{
+		{From: "/notes", To: "/api/v1/notes"},
+		{From: "/todos", To: "/api/v1/todos"},
+		{From: "/quiz", To: "/api/v1/quiz"},
+		{From: "/api/notes", To: "/api/v1/notes"},
+		{From: "/api/todos", To: "/api/v1/todos"},
+		{From: "/api/quiz", To: "/api/v1/quiz"},
+		{From: "/openapi.json", To: "/api/openapi.json"},
+		{From: "/docs", To: "/api/docs"},
+	},
+}
+
+// Server is the API built on one Store.
+type Server struct {
+	Notes  *services.NoteService
+	Todos  *services.TodoService
+	Quiz   *services.QuizService
+	Router *mux.Router
+
+	cfg *config.Config
+}
+
+// New creates the services on store and registers every route on a router.
+func New(cfg *config.Config, store *db.Store, version string, logger *slog.Logger) (*Server, error) {
+	todoService := services.NewTodoService(store.Todos, store.UnitOfWork)
+	noteService := services.NewNoteService(store.Notes, store.UnitOfWork, services.ContentPolicy{
+		MaxContentBytes: cfg.NoteMaxContentBytes,
+		MaxContentChars: cfg.NoteMaxContentChars,
+		AllowHTML:       cfg.NoteAllowHTML,
+	}, logger)
+	exportService := services.NewExportService(store.UnitOfWork, noteService, logger)
+
+	quizService, err := services.NewQuizService(cfg.GeminiAPIKey, noteService, logger)
+	if err != nil {
+		return nil, err
+	}
+	ingestService, err := services.NewIngestService(cfg.GeminiAPIKey, noteService, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := []*handlers.DependencyCheck{
+		{Name: "notes_db", Check: noteService.Ping},
+		{Name: "todos_db", Check: todoService.Ping},
+	}
+	if cfg.ReadinessCheckLLM {
+		checks = append(checks, &handlers.DependencyCheck{Name: "llm", Check: quizService.Ping, CacheFor: cfg.ReadinessLLMCache})
+	}
+	healthHandler := handlers.NewHealthHandler(version, logger, checks...)
+	if store.Pool != nil && cfg.DebugEndpoints {
+		healthHandler.SetPoolStats(store.Pool.Stats)
+	}
+
+	router := mux.NewRouter()
+
+	router.Use(tracing.RouteMiddleware)
+	router.Use(metrics.Middleware)
+	router.Use(jsonMiddleware)
+	router.Use(newUTF8Middleware(jsonBodyLimits(cfg)))
+
+	// Operational endpoints stay at the root where probes and scrapers expect
+	// them; everything the frontend and clients call lives under /api.
+	healthHandler.RegisterRoutes(router)
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	// The OpenAPI document covers every version, so it sits above them.
+	api := router.PathPrefix("/api").Subrouter()
+	openapi.RegisterRoutes(api)
+
+	quizHandler := handlers.NewQuizHandler(quizService, logger)
+
+	v1 := api.PathPrefix("/v1").Subrouter()
+	handlers.NewTodoHandler(todoService).RegisterRoutes(v1)
+	handlers.NewNoteHandler(noteService, logger).RegisterRoutes(v1)
+	handlers.NewImportHandler(noteService, logger).RegisterRoutes(v1)
+	handlers.NewExportHandler(exportService, logger).RegisterRoutes(v1)
+	handlers.NewIngestHandler(ingestService, logger).RegisterRoutes(v1)
+	quizHandler.RegisterRoutes(v1)
+
+	v2 := api.PathPrefix("/v2").Subrouter()
+	quizHandler.RegisterV2Routes(v2)
+
+	// The frontend only gets requests no route matched, so unknown /api
+	// paths never fall through to its index.html fallback.
+	var frontend http.Handler
+	if cfg.ServeFrontend {
+		embedded := web.NewHandler()
+		if !embedded.Built() {
+			logger.Warn("SERVE_FRONTEND is set but no frontend build is embedded; run `make web` and rebuild")
+		}
+		frontend = embedded
+	}
+	router.NotFoundHandler = notFoundHandler(router, frontend)
+
+	return &Server{Notes: noteService, Todos: todoService, Quiz: quizService, Router: router, cfg: cfg}, nil
+}
+
+// Handler returns the router behind the configured CORS policy and, when
+// enabled, the deprecated legacy path aliases.
+func (s *Server) Handler() http.Handler {
+	var routes http.Handler = s.Router
+	if s.cfg.LegacyRoutes {
+		routes = legacyRoutes.Handler(routes)
+	}
+	return newCORS(s.cfg).Handler(routes)
+}
+
+func newCORS(cfg *config.Config) *cors.Cors {
+	return cors.New(cors.Options{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowedMethods:   cfg.CORSAllowedMethods,
+		AllowedHeaders:   cfg.CORSAllowedHeaders,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		MaxAge:           int(cfg.CORSMaxAge.Seconds()),
+		// Let browser clients see that they are calling a deprecated alias.
+		ExposedHeaders: []string{"Deprecation", "Sunset", "Link"},
+	})
+}
+
+func jsonMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-ai-eng-flashcards/config"
+	"go-ai-eng-flashcards/db"
+)
+
+// newTestHandler builds the API on in-memory storage with cfg, or the
+// defaults when cfg is nil.
+func newTestHandler(t *testing.T, cfg *config.Config) http.Handler {
+	t.Helper()
+	if cfg == nil {
+		cfg = config.Default()
+	}
+	cfg.GeminiAPIKey = "test-key"
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store, err := db.OpenStore(context.Background(), db.DriverMemory, "", db.PoolConfig{}, logger)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	srv, err := New(cfg, store, "test", logger)
+	if err != nil {
+		t.Fatalf("failed to build server: %v", err)
+	}
+	return srv.Handler()
+}
+
+// serve sends a request with an optional body to handler and returns the
+// recorded response.
+func serve(t *testing.T, handler http.Handler, method, path, contentType string, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"go-ai-eng-flashcards/config"
+	"go-ai-eng-flashcards/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+// maxJSONBytes caps the request bodies utf8Middleware buffers on routes
+// without a limit of their own, such as todos and quiz turns.
+const maxJSONBytes = 1 << 20
+
+// uploadRoutes take files in their own formats and encodings, by path
+// template. Their handlers decode and cap the bodies themselves; every other
+// route decodes its body as JSON whatever the Content-Type says.
+var uploadRoutes = map[string]bool{
+	"/api/v1/notes/import":          true,
+	"/api/v1/notes/import/obsidian": true,
+	"/api/v1/notes/import/anki":     true,
+	"/api/v1/notes/import/csv":      true,
+	"/api/v1/notes/ingest":          true,
+}
+
+// jsonBodyLimits gives the routes that take larger JSON bodies, by path
+// template, the limit their handlers enforce. Note routes get one that fits
+// the content policy.
+func jsonBodyLimits(cfg *config.Config) map[string]int64 {
+	// Escaping can double the size of content in JSON.
+	notes := int64(handlers.MaxImportBytes)
+	if cfg.NoteMaxContentBytes > 0 {
+		notes = min(notes, max(maxJSONBytes, 2*int64(cfg.NoteMaxContentBytes)))
+	}
+	return map[string]int64{
+		"/api/v1/notes":             notes,
+		"/api/v1/notes/{id:[0-9]+}": notes,
+		"/api/v1/import":            handlers.MaxRestoreBytes,
+	}
+}
+
+// newUTF8Middleware returns middleware that rejects requests whose query
+// string or body is not valid UTF-8 with a 400. Go's JSON decoder would
+// otherwise quietly turn invalid bytes, such as Latin-1 text from a
+// misconfigured client, into U+FFFD and store the damaged text. Handlers
+// decode bodies as JSON whatever their Content-Type, so every body is
+// checked except on uploadRoutes, whose handlers decode files in other
+// encodings themselves.
+//
+// Bodies are buffered to be checked, up to the route's entry in limits or
+// maxJSONBytes, and larger ones get a 413.
+func newUTF8Middleware(limits map[string]int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, values := range r.URL.Query() {
+				for _, value := range values {
+					if !utf8.ValidString(value) {
+						writeEncodingError(w, http.StatusBadRequest, "query string is not valid UTF-8")
+						return
+					}
+				}
+			}
+
+			var template string
+			if route := mux.CurrentRoute(r); route != nil {
+				template, _ = route.GetPathTemplate()
+			}
+			if uploadRoutes[template] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if charset := params["charset"]; charset != "" && !strings.EqualFold(charset, "utf-8") && !strings.EqualFold(charset, "utf8") {
+				writeEncodingError(w, http.StatusUnsupportedMediaType, "JSON must be sent as UTF-8, got charset "+charset)
+				return
+			}
+
+			limit := int64(maxJSONBytes)
+			if limits[template] > 0 {
+				limit = limits[template]
+			}
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					writeEncodingError(w, http.StatusRequestEntityTooLarge, "request body is too large")
+				} else {
+					writeEncodingError(w, http.StatusBadRequest, "failed to read request body")
+				}
+				return
+			}
+			if !utf8.Valid(body) {
+				writeEncodingError(w, http.StatusBadRequest, "request body is not valid UTF-8")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeEncodingError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go-ai-eng-flashcards/config"
+	"go-ai-eng-flashcards/db"
+
+	"github.com/gorilla/mux"
+)
+
+func TestUTF8JSONBodies(t *testing.T) {
+	handler := newTestHandler(t, nil)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		status      int
+		content     string
+	}{
+		{
+			name:        "multibyte",
+			contentType: "application/json",
+			body:        []byte(`{"title":"Þrymskviða","content":"Þórr 雷神 ⚡ — 🔨"}`),
+			status:      http.StatusCreated,
+			content:     "Þórr 雷神 ⚡ — 🔨",
+		},
+		{
+			// Decomposed "é" and "ö" are stored precomposed.
+			name:        "normalized to NFC",
+			contentType: "application/json; charset=utf-8",
+			body:        []byte("{\"content\":\"Cafe\u0301 in Tromso\u0308\"}"),
+			status:      http.StatusCreated,
+			content:     "Caf\u00e9 in Troms\u00f6",
+		},
+		{
+			name:        "Latin-1",
+			contentType: "application/json",
+			body:        []byte("{\"content\":\"Caf\xe9\"}"),
+			status:      http.StatusBadRequest,
+		},
+		{
+			// Handlers decode JSON whatever the Content-Type says.
+			name:        "Latin-1 without Content-Type",
+			contentType: "",
+			body:        []byte("{\"content\":\"Caf\xe9\"}"),
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "Latin-1 as text/plain",
+			contentType: "text/plain",
+			body:        []byte("{\"content\":\"Caf\xe9\"}"),
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "multibyte without Content-Type",
+			contentType: "",
+			body:        []byte(`{"content":"Þórr 雷神"}`),
+			status:      http.StatusCreated,
+			content:     "Þórr 雷神",
+		},
+		{
+			name:        "other charset",
+			contentType: "application/json; charset=iso-8859-1",
+			body:        []byte(`{"content":"Cafe"}`),
+			status:      http.StatusUnsupportedMediaType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, handler, http.MethodPost, "/api/v1/notes", tt.contentType, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusCreated {
+				return
+			}
+			var created struct {
+				ID int `json:"id"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+				t.Fatal(err)
+			}
+
+			// Read back what was stored, not what the create echoed.
+			rec = serve(t, handler, http.MethodGet, "/api/v1/notes/"+strconv.Itoa(created.ID), "", nil)
+			var stored struct {
+				Content string `json:"content"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &stored); err != nil {
+				t.Fatal(err)
+			}
+			if stored.Content != tt.content {
+				t.Fatalf("stored content = %+q, want %+q", stored.Content, tt.content)
+			}
+		})
+	}
+}
+
+func TestUTF8InvalidQueryString(t *testing.T) {
+	rec := serve(t, newTestHandler(t, nil), http.MethodGet, "/api/v1/notes?tag=%E9t%E9", "", nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestJSONBodyLimits(t *testing.T) {
+	handler := newTestHandler(t, nil)
+	// Valid JSON just over the default limit: a string padded with spaces.
+	large := []byte(`{"title":"t","description":"` + strings.Repeat(" ", maxJSONBytes) + `"}`)
+
+	for _, contentType := range []string{"application/json", "", "text/plain"} {
+		if rec := serve(t, handler, http.MethodPost, "/api/v1/todos", contentType, large); rec.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("large todo sent as %q: status = %d, want 413", contentType, rec.Code)
+		}
+	}
+	// Restores take whole databases, so the same body is read and refused
+	// only for not being an export.
+	if rec := serve(t, handler, http.MethodPost, "/api/v1/import", "application/json", large); rec.Code != http.StatusBadRequest {
+		t.Fatalf("large import status = %d, want 400; body %s", rec.Code, rec.Body)
+	}
+	// Notes may be as large as the content policy allows, 1 MiB by default.
+	note := []byte(`{"content":"` + strings.Repeat("x", 1<<20) + `"}`)
+	if rec := serve(t, handler, http.MethodPost, "/api/v1/notes", "application/json", note); rec.Code != http.StatusCreated {
+		t.Fatalf("1 MiB note status = %d, want 201; body %.200s", rec.Code, rec.Body)
+	}
+}
+
+// TestJSONBodyLimitsMatchRoutes keeps the limits in step with the routes,
+// since a renamed route would silently fall back to maxJSONBytes.
+func TestJSONBodyLimitsMatchRoutes(t *testing.T) {
+	cfg := config.Default()
+	cfg.GeminiAPIKey = "test-key"
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store, err := db.OpenStore(context.Background(), db.DriverMemory, "", db.PoolConfig{}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	srv, err := New(cfg, store, "test", logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templates := make(map[string]bool)
+	srv.Router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		if template, err := route.GetPathTemplate(); err == nil {
+			templates[template] = true
+		}
+		return nil
+	})
+	for template := range jsonBodyLimits(cfg) {
+		if !templates[template] {
+			t.Errorf("JSON body limit set for %s, which is not a route", template)
+		}
+	}
+	for template := range uploadRoutes {
+		if !templates[template] {
+			t.Errorf("%s is listed as an upload route, but is not a route", template)
+		}
+	}
+}
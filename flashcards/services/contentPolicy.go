@@ -0,0 +1,125 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ContentPolicy is the set of rules a note's text is held to. The same
+// policy applies when a note is created, updated or imported, so any note
+// that could be stored can also be edited.
+type ContentPolicy struct {
+	// MaxContentBytes and MaxContentChars cap a note's content after it is
+	// normalized, in bytes and in characters; 0 means no limit.
+	MaxContentBytes int
+	MaxContentChars int
+	// AllowHTML permits raw HTML tags in the Markdown content. Without it,
+	// content with tags outside code blocks and code spans is rejected.
+	AllowHTML bool
+}
+
+var (
+	codeSpanPattern  = regexp.MustCompile("`[^`\n]*`")
+	htmlTagPattern   = regexp.MustCompile(`<!--|</?[A-Za-z][A-Za-z0-9-]*(?:\s[^<>]*)?/?>`)
+	fenceLinePattern = regexp.MustCompile("^[ ]{0,3}(`{3,}|~{3,})")
+)
+
+// checkContent reports whether normalized content may be stored.
+func (p ContentPolicy) checkContent(content string) error {
+	if content == "" {
+		return fmt.Errorf("content is required")
+	}
+	if p.MaxContentBytes > 0 && len(content) > p.MaxContentBytes {
+		return fmt.Errorf("content cannot exceed %d bytes", p.MaxContentBytes)
+	}
+	if p.MaxContentChars > 0 && utf8.RuneCountInString(content) > p.MaxContentChars {
+		return fmt.Errorf("content cannot exceed %d characters", p.MaxContentChars)
+	}
+	if !p.AllowHTML {
+		if tag := htmlTag(content); tag != "" {
+			return fmt.Errorf("content cannot contain HTML, found %s", tag)
+		}
+	}
+	return nil
+}
+
+// htmlTag returns the first raw HTML tag or comment in Markdown content,
+// ignoring fenced code blocks and code spans, or "" if there is none.
+func htmlTag(content string) string {
+	fence := ""
+	for _, line := range strings.Split(content, "\n") {
+		if m := fenceLinePattern.FindStringSubmatch(line); m != nil {
+			switch {
+			case fence == "":
+				fence = m[1]
+				continue
+			case m[1][0] == fence[0] && len(m[1]) >= len(fence):
+				fence = ""
+				continue
+			}
+		}
+		if fence != "" {
+			continue
+		}
+		if tag := htmlTagPattern.FindString(codeSpanPattern.ReplaceAllString(line, "")); tag != "" {
+			return tag
+		}
+	}
+	return ""
+}
+
+// checkUTF8 reports a note's text that is not valid UTF-8. It runs before
+// normalization, which would otherwise hide invalid bytes behind U+FFFD.
+// Notes sent as JSON never get here with invalid bytes, since the decoder
+// replaces them and the server rejects such bodies before decoding; this is
+// for notes read from imported files and other callers.
+func checkUTF8(title, content string, tags []string) error {
+	if !utf8.ValidString(title) {
+		return fmt.Errorf("title is not valid UTF-8")
+	}
+	if !utf8.ValidString(content) {
+		return fmt.Errorf("content is not valid UTF-8")
+	}
+	for _, tag := range tags {
+		if !utf8.ValidString(tag) {
+			return fmt.Errorf("tag %q is not valid UTF-8", strings.ToValidUTF8(tag, "\uFFFD"))
+		}
+	}
+	return nil
+}
+
+// normalizeText cleans a note's text the same way wherever it came from:
+// line endings become \n, control characters other than newlines and tabs
+// are removed, the text is put in Unicode NFC so equal text is stored as
+// equal bytes, and surrounding whitespace is trimmed.
+func normalizeText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\r':
+			return '\n'
+		case r == '\n' || r == '\t':
+			return r
+		case unicode.IsControl(r):
+			return -1
+		}
+		return r
+	}, text)
+	return strings.TrimSpace(norm.NFC.String(text))
+}
+
+// normalizeLine normalizes single-line text, such as a title or a tag, with
+// line breaks and tabs turned into spaces.
+func normalizeLine(text string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return ' '
+		}
+		return r
+	}, normalizeText(text)))
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"go-ai-eng-flashcards/db"
+	"go-ai-eng-flashcards/exporter"
+	"go-ai-eng-flashcards/models"
+)
+
+// ErrInvalidExport is wrapped by the errors Restore returns for documents it
+// refuses before writing anything.
+var ErrInvalidExport = errors.New("invalid export")
+
+// ExportService reads and restores everything stored, for backups and moves
+// between instances.
+type ExportService struct {
+	uow         db.UnitOfWork
+	noteService *NoteService
+	logger      *slog.Logger
+}
+
+// NewExportService creates an ExportService. Restored notes are checked and
+// normalized by noteService, under its content policy.
+func NewExportService(uow db.UnitOfWork, noteService *NoteService, logger *slog.Logger) *ExportService {
+	return &ExportService{uow: uow, noteService: noteService, logger: logger}
+}
+
+// WriteExport writes every note, note link and todo to w in format, each
+// record as it is read from Postgres, so the server never holds a whole
+// export. Notes soft-deleted because their source file went away are
+// included with their links, so a restore brings them back exactly as they
+// were. Everything is read from one consistent view, so a concurrent import
+// is either fully included or not at all, and writes are not held up while
+// a slow client downloads.
+func (s *ExportService) WriteExport(ctx context.Context, w io.Writer, format exporter.Format, exportedAt time.Time) error {
+	s.logger.Info("Attempting to export all data", slog.String("format", string(format)))
+
+	var src *exportSource
+	err := s.uow.DoReadOnly(ctx, func(ctx context.Context, repos db.Repositories) error {
+		src = &exportSource{ctx: ctx, repos: repos}
+		return exporter.Write(w, format, exportedAt, src)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export data: %w", err)
+	}
+
+	s.logger.Info("Data exported successfully", slog.Int("notes", src.notes), slog.Int("note_links", src.links), slog.Int("todos", src.todos))
+	return nil
+}
+
+// exportSource reads an export from the repositories of one unit of work
+// and counts what it yields.
+type exportSource struct {
+	ctx                 context.Context
+	repos               db.Repositories
+	notes, links, todos int
+}
+
+func (s *exportSource) EachNote(fn func(*models.Note) error) error {
+	return s.repos.Notes.EachNoteIncludingDeleted(s.ctx, func(note *models.Note) error {
+		s.notes++
+		return fn(note)
+	})
+}
+
+func (s *exportSource) EachNoteLink(fn func(models.NoteLink) error) error {
+	return s.repos.Notes.EachNoteLinkIncludingDeleted(s.ctx, func(link models.NoteLink) error {
+		s.links++
+		return fn(link)
+	})
+}
+
+func (s *exportSource) EachTodo(fn func(*models.Todo) error) error {
+	return s.repos.Todos.EachTodo(s.ctx, func(todo *models.Todo) error {
+		s.todos++
+		return fn(todo)
+	})
+}
+
+// Restore writes an Export back in one unit of work. Notes and todos keep
+// their IDs and timestamps and replace any stored with the same ID; each
+// restored note's links are replaced by the document's. Nothing else is
+// removed, so restoring into a non-empty database merges into it.
+//
+// Every note is normalized and checked like a created one, against the
+// content policy in force now. A document with a note that fails is
+// rejected whole, naming the note, since restoring only part of a backup
+// would leave links and IDs pointing at notes that are not there.
+func (s *ExportService) Restore(ctx context.Context, export *models.Export) (*models.RestoreResult, error) {
+	s.logger.Info("Attempting to restore an export", slog.Int("notes", len(export.Notes)), slog.Int("todos", len(export.Todos)))
+	if err := validateExport(export); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidExport, err)
+	}
+	if err := s.prepareNotes(export.Notes); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidExport, err)
+	}
+
+	links := make(map[int][]int64, len(export.Notes))
+	for _, note := range export.Notes {
+		links[note.ID] = nil
+	}
+	for _, link := range export.NoteLinks {
+		links[link.From] = append(links[link.From], int64(link.To))
+	}
+
+	err := s.uow.Do(ctx, func(ctx context.Context, repos db.Repositories) error {
+		if err := repos.Notes.RestoreNotes(ctx, export.Notes); err != nil {
+			return err
+		}
+		for _, note := range export.Notes {
+			if err := repos.Notes.SetNoteLinks(ctx, int64(note.ID), links[note.ID]); err != nil {
+				return err
+			}
+			// Chunks are derived from the content, so they are not exported.
+			if err := storeChunks(ctx, repos.Notes, note); err != nil {
+				return err
+			}
+		}
+		return repos.Todos.RestoreTodos(ctx, export.Todos)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.RestoreResult{Notes: len(export.Notes), NoteLinks: len(export.NoteLinks), Todos: len(export.Todos)}
+	s.logger.Info("Export restored successfully", slog.Int("notes", result.Notes), slog.Int("note_links", result.NoteLinks), slog.Int("todos", result.Todos))
+	return result, nil
+}
+
+// prepareNotes replaces the title, content and tags of each note with their
+// normalized form, or returns the first note's error.
+func (s *ExportService) prepareNotes(notes []*models.Note) error {
+	for _, note := range notes {
+		prepared, err := s.noteService.prepareNote(&models.CreateNoteRequest{Title: note.Title, Content: note.Content, Tags: note.Tags})
+		if err != nil {
+			return fmt.Errorf("note %d: %w", note.ID, err)
+		}
+		note.Title, note.Content, note.Tags = prepared.Title, prepared.Content, prepared.Tags
+	}
+	return nil
+}
+
+func validateExport(export *models.Export) error {
+	if export.Version != models.ExportVersion {
+		return fmt.Errorf("unsupported version %d, expected %d", export.Version, models.ExportVersion)
+	}
+
+	notes := make(map[int]bool, len(export.Notes))
+	for _, note := range export.Notes {
+		if note == nil || note.ID <= 0 {
+			return fmt.Errorf("every note needs a positive id")
+		}
+		if notes[note.ID] {
+			return fmt.Errorf("note id %d appears more than once", note.ID)
+		}
+		notes[note.ID] = true
+	}
+	for _, link := range export.NoteLinks {
+		if !notes[link.From] || !notes[link.To] {
+			return fmt.Errorf("link from note %d to note %d refers to a note not in the export", link.From, link.To)
+		}
+	}
+
+	todos := make(map[int]bool, len(export.Todos))
+	for _, todo := range export.Todos {
+		if todo == nil || todo.ID <= 0 {
+			return fmt.Errorf("every todo needs a positive id")
+		}
+		if todos[todo.ID] {
+			return fmt.Errorf("todo id %d appears more than once", todo.ID)
+		}
+		todos[todo.ID] = true
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go-ai-eng-flashcards/importer"
+	"go-ai-eng-flashcards/ingest"
+	"go-ai-eng-flashcards/metrics"
+	"go-ai-eng-flashcards/models"
+	"go-ai-eng-flashcards/tracing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/googleai"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	condensePrompt = `You turn passages from a document into study notes for flashcards.
+
+Reply with a short title for the note on the first line, then a blank line, then the note itself: the key facts, definitions and reasoning of the passage, in concise sentences or a short bulleted list. Use only information from the passage, keep its terminology, and do not mention "the passage" or "the text".`
+	condenseTemplate = "Document: %s\nSection: %s\n\nPassage:\n%s"
+
+	// condenseOperation labels LLM metrics recorded for condensing passages.
+	condenseOperation = "condense"
+	// condenseWorkers is how many passages are condensed at once.
+	condenseWorkers = 4
+	// MaxCondensedChunks caps the passages one request may condense, so it
+	// finishes within the server's write timeout.
+	MaxCondensedChunks = 40
+)
+
+// ErrTooManyChunks is returned when condensing is asked for a document with
+// more than MaxCondensedChunks passages.
+var ErrTooManyChunks = errors.New("too many passages to condense")
+
+// IngestOptions controls IngestDocument.
+type IngestOptions struct {
+	// ChunkSize is the longest passage in characters; 0 means the default.
+	ChunkSize int
+	// Condense asks the LLM to rewrite each passage as a study note.
+	Condense bool
+	Tags     []string
+}
+
+// IngestService turns documents into notes, optionally condensing their
+// passages with an LLM.
+type IngestService struct {
+	llm         *googleai.GoogleAI
+	noteService *NoteService
+	logger      *slog.Logger
+}
+
+// NewIngestService creates a new instance of IngestService.
+func NewIngestService(apiKey string, noteService *NoteService, logger *slog.Logger) (*IngestService, error) {
+	logger.Info("Initializing IngestService")
+	llm, err := googleai.New(context.Background(), googleai.WithAPIKey(apiKey))
+	if err != nil {
+		logger.Error("Failed to initialize Gemini LLM", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to initialize Gemini LLM: %w", err)
+	}
+	logger.Info("IngestService initialized successfully")
+	return &IngestService{llm: llm, noteService: noteService, logger: logger}, nil
+}
+
+// IngestDocument cuts a document into passages and stores one note per
+// passage, in one transaction. With opts.Condense each note's content is the
+// LLM's study note for the passage instead of its text; a passage the LLM
+// fails on keeps its text, and its metadata says it was not condensed.
+func (s *IngestService) IngestDocument(ctx context.Context, name string, doc *ingest.Document, opts IngestOptions) (*models.IngestResult, error) {
+	s.logger.Info("Attempting to ingest a document", slog.String("name", name), slog.String("type", string(doc.Kind)), slog.Bool("condense", opts.Condense))
+
+	chunks := ingest.Split(doc, opts.ChunkSize)
+	if opts.Condense && len(chunks) > MaxCondensedChunks {
+		return nil, fmt.Errorf("%w: %s has %d passages and at most %d can be condensed at once; ingest it without condensing, with a larger chunk_size, or in parts",
+			ErrTooManyChunks, name, len(chunks), MaxCondensedChunks)
+	}
+
+	notes, err := importer.DocumentNotes(name, doc, chunks)
+	if err != nil {
+		return nil, err
+	}
+	for i := range notes {
+		notes[i].Tags = normalizeTags(slices.Concat(notes[i].Tags, opts.Tags))
+	}
+	condensed := 0
+	if opts.Condense {
+		condensed = s.condenseNotes(ctx, name, doc, chunks, notes)
+	}
+
+	imported, err := s.noteService.ImportNotes(ctx, notes)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.IngestResult{
+		ImportResult: *imported,
+		Document: models.IngestedDocument{
+			Name:      name,
+			Title:     doc.Title,
+			Type:      string(doc.Kind),
+			Pages:     doc.Pages,
+			Chunks:    len(chunks),
+			Condensed: condensed,
+		},
+	}
+	s.logger.Info("Document ingested successfully", slog.String("name", name), slog.Int("chunks", len(chunks)), slog.Int("condensed", condensed), slog.Int("created", imported.Created))
+	return result, nil
+}
+
+// condenseNotes replaces the title and content of each note with the LLM's
+// study note for its passage, a few passages at a time, and returns how
+// many were condensed.
+func (s *IngestService) condenseNotes(ctx context.Context, name string, doc *ingest.Document, chunks []ingest.Chunk, notes []importer.Note) int {
+	documentTitle := doc.Title
+	if documentTitle == "" {
+		documentTitle = name
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		condensed int
+		slots     = make(chan struct{}, condenseWorkers)
+	)
+	for i := range notes {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer func() { <-slots; wg.Done() }()
+
+			meta, ok := notes[i].Metadata[importer.DocumentMetadataKey].(map[string]any)
+			if !ok {
+				s.logger.Error("Passage has no document metadata; keeping its text", slog.String("source", notes[i].Source))
+				return
+			}
+			title, content, err := s.condense(ctx, documentTitle, chunks[i])
+			if err != nil {
+				s.logger.Error("Failed to condense passage; keeping its text", slog.String("source", notes[i].Source), slog.Any("error", err))
+				return
+			}
+			if title != "" {
+				notes[i].Title = title
+			}
+			notes[i].Content = content
+			meta["condensed"] = true
+
+			mu.Lock()
+			condensed++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return condensed
+}
+
+// condense asks the LLM for a study note on one passage and splits the reply
+// into its title and content.
+func (s *IngestService) condense(ctx context.Context, documentTitle string, chunk ingest.Chunk) (string, string, error) {
+	section := strings.Join(chunk.Heading, " > ")
+	if section == "" && chunk.FirstPage > 0 {
+		section = fmt.Sprintf("page %d", chunk.FirstPage)
+	}
+	userPrompt := fmt.Sprintf(condenseTemplate, documentTitle, section, chunk.Text)
+	messages := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, condensePrompt),
+		llms.TextParts(llms.ChatMessageTypeHuman, userPrompt),
+	}
+
+	ctx, span := tracing.StartSpan(ctx, "llm.GenerateContent",
+		attribute.String("gen_ai.system", "gemini"),
+		attribute.String("gen_ai.operation.name", condenseOperation),
+		attribute.Int("gen_ai.prompt.chars", len(condensePrompt)+len(userPrompt)),
+	)
+	start := time.Now()
+	completion, err := s.llm.GenerateContent(ctx, messages, llms.WithTemperature(0.2))
+	metrics.ObserveLLMCall(condenseOperation, start, err)
+	if err != nil {
+		tracing.EndSpan(span, err)
+		return "", "", fmt.Errorf("failed to generate study note: %w", err)
+	}
+	if len(completion.Choices) == 0 || strings.TrimSpace(completion.Choices[0].Content) == "" {
+		metrics.ObserveLLMEmptyResponse(condenseOperation)
+		tracing.EndSpan(span, nil)
+		return "", "", fmt.Errorf("LLM returned an empty study note")
+	}
+	promptTokens, completionTokens := tokenUsage(completion.Choices[0].GenerationInfo)
+	metrics.ObserveLLMTokens(condenseOperation, promptTokens, completionTokens)
+	span.SetAttributes(
+		attribute.Int("gen_ai.usage.input_tokens", promptTokens),
+		attribute.Int("gen_ai.usage.output_tokens", completionTokens),
+	)
+	tracing.EndSpan(span, nil)
+
+	title, content := splitStudyNote(strings.ToValidUTF8(completion.Choices[0].Content, "\uFFFD"))
+	return title, content, nil
+}
+
+// splitStudyNote splits an LLM reply into the title on its first line and
+// the note after it. Markdown heading and bold markers are dropped from the
+// title. A reply with nothing after its first line is all content.
+func splitStudyNote(reply string) (string, string) {
+	reply = strings.TrimSpace(reply)
+	first, rest, found := strings.Cut(reply, "\n")
+	rest = strings.TrimSpace(rest)
+	if !found || rest == "" {
+		return "", reply
+	}
+
+	title := strings.Trim(first, "#*_ ")
+	title = strings.Trim(strings.TrimPrefix(title, "Title:"), "#*_ ")
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		return "", reply
+	}
+	return title, rest
+}
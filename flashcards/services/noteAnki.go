@@ -0,0 +1,106 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"go-ai-eng-flashcards/anki"
+	"go-ai-eng-flashcards/importer"
+	"go-ai-eng-flashcards/models"
+)
+
+// DefaultAnkiDeck is the deck exported notes go to when they did not come
+// from Anki.
+const DefaultAnkiDeck = "Flashcards"
+
+// Note types used for exported notes. Notes keep the GUID they had in Anki,
+// but not its note type, since their text may have been edited into a shape
+// the original fields cannot hold.
+const (
+	ankiBasicType = "Basic (flashcards)"
+	ankiClozeType = "Cloze (flashcards)"
+)
+
+var clozeMarker = regexp.MustCompile(`\{\{c\d+::`)
+
+// ExportAnki returns every note as an .apkg package. Notes with a title use
+// it as the front of the card and their content as the back; notes without
+// one use the first line of their content, as the study screen does. Notes
+// containing {{c1::...}} deletions become cloze notes. Notes imported from
+// Anki go back to their deck with their scheduling; all others go to deck,
+// or DefaultAnkiDeck if it is empty.
+func (s *NoteService) ExportAnki(ctx context.Context, deck string) ([]byte, error) {
+	s.logger.Info("Attempting to export notes to Anki", slog.String("deck", deck))
+	if deck == "" {
+		deck = DefaultAnkiDeck
+	}
+
+	notes, err := s.repo.GetAllNotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ankiNotes := make([]anki.Note, 0, len(notes))
+	for _, note := range notes {
+		an, err := ankiNote(note, deck)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export note %d: %w", note.ID, err)
+		}
+		ankiNotes = append(ankiNotes, an)
+	}
+
+	var buf bytes.Buffer
+	if err := anki.WritePackage(&buf, ankiNotes); err != nil {
+		return nil, fmt.Errorf("failed to write Anki package: %w", err)
+	}
+
+	s.logger.Info("Notes exported to Anki successfully", slog.Int("count", len(ankiNotes)), slog.Int("bytes", buf.Len()))
+	return buf.Bytes(), nil
+}
+
+func ankiNote(note *models.Note, deck string) (anki.Note, error) {
+	an := anki.Note{
+		GUID:     importer.ExportedGUID(note.ID),
+		Deck:     deck,
+		Modified: note.UpdatedAt,
+	}
+	for _, tag := range note.Tags {
+		an.Tags = append(an.Tags, strings.ReplaceAll(tag, "/", "::"))
+	}
+
+	meta, err := importer.ParseAnkiMetadata(note.Metadata)
+	if err != nil {
+		return an, err
+	}
+	if meta != nil {
+		an.GUID = meta.GUID
+		an.Cards = meta.Cards
+		if meta.Deck != "" {
+			an.Deck = meta.Deck
+		}
+	}
+
+	if clozeMarker.MatchString(note.Content) {
+		an.NoteType, an.Kind = ankiClozeType, anki.KindCloze
+		an.Fields = []anki.Field{
+			{Name: "Text", Value: anki.TextToHTML(note.Content)},
+			{Name: "Back Extra", Value: anki.TextToHTML(note.Title)},
+		}
+		return an, nil
+	}
+
+	front, back := note.Title, note.Content
+	if front == "" {
+		front, back, _ = strings.Cut(note.Content, "\n")
+	}
+	an.NoteType, an.Kind = ankiBasicType, anki.KindStandard
+	an.Fields = []anki.Field{
+		{Name: "Front", Value: anki.TextToHTML(strings.TrimSpace(front))},
+		{Name: "Back", Value: anki.TextToHTML(strings.TrimSpace(back))},
+	}
+	return an, nil
+}
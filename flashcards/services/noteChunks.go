@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"unicode/utf8"
+
+	"go-ai-eng-flashcards/db"
+	"go-ai-eng-flashcards/importer"
+	"go-ai-eng-flashcards/ingest"
+	"go-ai-eng-flashcards/models"
+)
+
+// noteChunkSize is the longest chunk of a note, in characters. Notes no
+// longer than this are a single chunk.
+const noteChunkSize = ingest.DefaultChunkSize
+
+// chunkNote cuts a note's content into ordered chunks. Long notes are split
+// at their Markdown headings, then paragraphs and sentences, the same way
+// ingested documents are.
+func chunkNote(note *models.Note) []models.NoteChunk {
+	if utf8.RuneCountInString(note.Content) <= noteChunkSize {
+		return []models.NoteChunk{{NoteID: note.ID, Position: 1, Heading: []string{}, Content: note.Content}}
+	}
+
+	passages := ingest.Split(importer.MarkdownDocument(note.Content), noteChunkSize)
+	chunks := make([]models.NoteChunk, len(passages))
+	for i, passage := range passages {
+		heading := passage.Heading
+		if heading == nil {
+			heading = []string{}
+		}
+		chunks[i] = models.NoteChunk{NoteID: note.ID, Position: i + 1, Heading: heading, Content: passage.Text}
+	}
+	return chunks
+}
+
+// storeChunks replaces the stored chunks of note with ones cut from its
+// current content.
+func storeChunks(ctx context.Context, repo db.NoteRepository, note *models.Note) error {
+	if err := repo.SetNoteChunks(ctx, int64(note.ID), chunkNote(note)); err != nil {
+		return fmt.Errorf("failed to store chunks of note %d: %w", note.ID, err)
+	}
+	return nil
+}
+
+// GetNoteChunks returns the chunks of a note in order. A note stored before
+// notes were chunked is chunked on the fly.
+func (s *NoteService) GetNoteChunks(ctx context.Context, id int64) ([]models.NoteChunk, error) {
+	s.logger.Info("Attempting to retrieve note chunks", slog.Any("note_id", id))
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid note ID: %d", id)
+	}
+
+	note, err := s.repo.GetNoteById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := s.repo.GetNoteChunks(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 {
+		chunks = chunkNote(note)
+	}
+
+	s.logger.Info("Note chunks retrieved successfully", slog.Any("note_id", id), slog.Int("count", len(chunks)))
+	return chunks, nil
+}
+
+// GetAllNotesWithChunks returns every note along with its chunks by note
+// ID. Notes stored before notes were chunked are chunked on the fly.
+func (s *NoteService) GetAllNotesWithChunks(ctx context.Context) ([]*models.Note, map[int][]models.NoteChunk, error) {
+	s.logger.Info("Attempting to retrieve all notes with their chunks")
+	notes, err := s.repo.GetAllNotes(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	stored, err := s.repo.ListNoteChunks(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chunks := make(map[int][]models.NoteChunk, len(notes))
+	for _, chunk := range stored {
+		chunks[chunk.NoteID] = append(chunks[chunk.NoteID], chunk)
+	}
+	for _, note := range notes {
+		if len(chunks[note.ID]) == 0 {
+			chunks[note.ID] = chunkNote(note)
+		}
+	}
+
+	s.logger.Info("All notes with chunks retrieved successfully", slog.Int("notes", len(notes)), slog.Int("stored_chunks", len(stored)))
+	return notes, chunks, nil
+}
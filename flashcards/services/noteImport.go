@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+
+	"go-ai-eng-flashcards/db"
+	"go-ai-eng-flashcards/importer"
+	"go-ai-eng-flashcards/models"
+)
+
+// ImportNotes stores parsed notes in a single unit of work. Notes that fail
+// validation are reported as failed and skipped; a storage error rolls back
+// the whole import and is returned, so either every valid note is stored or
+// none are.
+//
+// A note with a SourcePath that is already stored updates the existing note,
+// restoring it if it was soft-deleted, instead of creating another; so does
+// a note with the NoteID of a stored note. Once every note is stored, their links are
+// resolved against the other notes of the same import and replace the
+// stored links of each note.
+func (s *NoteService) ImportNotes(ctx context.Context, notes []importer.Note) (*models.ImportResult, error) {
+	s.logger.Info("Attempting to import notes", slog.Int("count", len(notes)))
+
+	var result *models.ImportResult
+	err := s.uow.Do(ctx, func(ctx context.Context, repos db.Repositories) error {
+		result = &models.ImportResult{Items: make([]models.ImportItemResult, 0, len(notes))}
+		ids := make([]int64, len(notes))
+		for i, parsed := range notes {
+			item := models.ImportItemResult{Source: parsed.Source, Title: parsed.Title}
+
+			note, err := s.prepareNote(&models.CreateNoteRequest{Title: parsed.Title, Content: parsed.Content, Tags: parsed.Tags})
+			if err != nil {
+				item.Status = models.ImportFailed
+				item.Error = err.Error()
+				result.Add(item)
+				continue
+			}
+			note.Metadata = parsed.Metadata
+			note.SourcePath = parsed.SourcePath
+			status, err := upsertNote(ctx, repos.Notes, note, parsed.NoteID)
+			if err != nil {
+				return fmt.Errorf("failed to import %s: %w", parsed.Source, err)
+			}
+			if err := storeChunks(ctx, repos.Notes, note); err != nil {
+				return fmt.Errorf("failed to import %s: %w", parsed.Source, err)
+			}
+			ids[i] = int64(note.ID)
+			item.Status = status
+			item.NoteID = note.ID
+			result.Add(item)
+		}
+		return linkImportedNotes(ctx, repos.Notes, notes, ids, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Notes imported successfully", slog.Int("created", result.Created), slog.Int("updated", result.Updated), slog.Int("failed", result.Failed))
+	return result, nil
+}
+
+// PreviewImport reports what ImportNotes would do with notes without storing
+// anything: which notes fail validation, and which would be created or
+// would update a note with the same source path. Items carry the content
+// and tags that would be stored.
+func (s *NoteService) PreviewImport(ctx context.Context, notes []importer.Note) (*models.ImportResult, error) {
+	s.logger.Info("Attempting to preview an import", slog.Int("count", len(notes)))
+
+	result := &models.ImportResult{Items: make([]models.ImportItemResult, 0, len(notes)), DryRun: true}
+	for _, parsed := range notes {
+		item := models.ImportItemResult{Source: parsed.Source, Title: parsed.Title}
+
+		note, err := s.prepareNote(&models.CreateNoteRequest{Title: parsed.Title, Content: parsed.Content, Tags: parsed.Tags})
+		if err != nil {
+			item.Status = models.ImportFailed
+			item.Error = err.Error()
+			result.Add(item)
+			continue
+		}
+		item.Content = note.Content
+		item.Tags = note.Tags
+		item.Status = models.ImportCreated
+		existing, _, err := importedNote(ctx, s.repo, parsed.SourcePath, parsed.NoteID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to preview %s: %w", parsed.Source, err)
+		}
+		if existing != nil {
+			item.Status = models.ImportUpdated
+			item.NoteID = existing.ID
+		}
+		result.Add(item)
+	}
+
+	s.logger.Info("Import previewed successfully", slog.Int("created", result.Created), slog.Int("updated", result.Updated), slog.Int("failed", result.Failed))
+	return result, nil
+}
+
+// upsertNote creates note, or updates the stored note it was exported from
+// or that has the same source path, and sets note.ID to the stored note's
+// ID. A soft-deleted note is restored by the update.
+func upsertNote(ctx context.Context, repo db.NoteRepository, note *models.Note, exportedFrom int) (models.ImportStatus, error) {
+	existing, byID, err := importedNote(ctx, repo, note.SourcePath, exportedFrom)
+	if err != nil {
+		return "", err
+	}
+	if existing == nil {
+		if err := repo.CreateNote(ctx, note); err != nil {
+			return "", err
+		}
+		return models.ImportCreated, nil
+	}
+
+	metadata := note.Metadata
+	if byID {
+		// The note keeps its own source path and metadata, with what the
+		// import knows added, so it can go on being synced from its source.
+		metadata = maps.Clone(existing.Metadata)
+		if metadata == nil {
+			metadata = make(map[string]any)
+		}
+		maps.Copy(metadata, note.Metadata)
+	}
+	updates := map[string]any{
+		"title":    note.Title,
+		"content":  note.Content,
+		"tags":     note.Tags,
+		"metadata": metadata,
+	}
+	if existing.DeletedAt != nil {
+		updates["deleted_at"] = nil
+	}
+	if err := repo.UpdateNote(ctx, int64(existing.ID), updates); err != nil {
+		return "", err
+	}
+	note.ID = existing.ID
+	return models.ImportUpdated, nil
+}
+
+// importedNote returns the stored note an imported note updates, or nil if
+// it is new: the note with ID exportedFrom if it is still there and was not
+// itself imported from Anki, else the note with sourcePath. byID reports
+// which one it is. A note imported from Anki is exported with its Anki GUID,
+// so an exported note ID that names one comes from another collection.
+func importedNote(ctx context.Context, repo db.NoteRepository, sourcePath string, exportedFrom int) (note *models.Note, byID bool, err error) {
+	if exportedFrom > 0 {
+		note, err := repo.GetNoteById(ctx, int64(exportedFrom))
+		if err != nil && !errors.Is(err, db.ErrNoteNotFound) {
+			return nil, false, err
+		}
+		if note != nil {
+			meta, err := importer.ParseAnkiMetadata(note.Metadata)
+			if err != nil {
+				return nil, false, err
+			}
+			if meta == nil || meta.GUID == importer.ExportedGUID(exportedFrom) {
+				return note, true, nil
+			}
+		}
+	}
+	if sourcePath == "" {
+		return nil, false, nil
+	}
+	note, err = repo.GetNoteBySourcePath(ctx, sourcePath)
+	return note, false, err
+}
+
+// linkImportedNotes replaces the stored links of every imported note with a
+// source path, so a re-import also drops links that were removed. ids holds
+// the stored ID of each note, or 0 where it failed validation. Notes without
+// link keys, such as split Markdown sections, are left alone.
+func linkImportedNotes(ctx context.Context, repo db.NoteRepository, notes []importer.Note, ids []int64, result *models.ImportResult) error {
+	byKey := make(map[string]int64)
+	for i, parsed := range notes {
+		if ids[i] == 0 {
+			continue
+		}
+		for _, key := range parsed.LinkKeys {
+			if _, taken := byKey[key]; !taken {
+				byKey[key] = ids[i]
+			}
+		}
+	}
+	if len(byKey) == 0 {
+		return nil
+	}
+
+	// result.Items[i] is still the item for notes[i].
+	for i, parsed := range notes {
+		if ids[i] == 0 || parsed.SourcePath == "" {
+			continue
+		}
+		var targets []int64
+		for _, link := range parsed.Links {
+			target, ok := byKey[link]
+			if !ok {
+				result.Items[i].UnresolvedLinks = append(result.Items[i].UnresolvedLinks, link)
+				continue
+			}
+			if target != ids[i] {
+				targets = append(targets, target)
+			}
+		}
+		if err := repo.SetNoteLinks(ctx, ids[i], targets); err != nil {
+			return fmt.Errorf("failed to link %s: %w", parsed.Source, err)
+		}
+	}
+	return nil
+}
+
+// GetNoteLinks returns the notes a note links to and the notes linking to it.
+func (s *NoteService) GetNoteLinks(ctx context.Context, id int64) (*models.NoteLinks, error) {
+	s.logger.Info("Attempting to retrieve note links", slog.Any("note_id", id))
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid note ID: %d", id)
+	}
+
+	links, err := s.repo.GetNoteLinks(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Note links retrieved successfully", slog.Any("note_id", id), slog.Int("outgoing", len(links.Outgoing)), slog.Int("incoming", len(links.Incoming)))
+	return links, nil
+}
@@ -1,33 +1,40 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"go-ai-eng-flashcards/db"
 	"go-ai-eng-flashcards/models"
 	"log/slog"
-	"strings"
+	"slices"
+	"unicode/utf8"
 )
 
 type NoteService struct {
 	repo   db.NoteRepository
+	uow    db.UnitOfWork
+	policy ContentPolicy
 	logger *slog.Logger
 }
 
-func NewNoteService(repo db.NoteRepository, logger *slog.Logger) *NoteService {
-	return &NoteService{repo: repo, logger: logger}
+func NewNoteService(repo db.NoteRepository, uow db.UnitOfWork, policy ContentPolicy, logger *slog.Logger) *NoteService {
+	return &NoteService{repo: repo, uow: uow, policy: policy, logger: logger}
 }
 
-func (s *NoteService) CreateNote(req *models.CreateNoteRequest) (*models.Note, error) {
+func (s *NoteService) CreateNote(ctx context.Context, req *models.CreateNoteRequest) (*models.Note, error) {
 	s.logger.Info("Attempting to create a new note", slog.Any("content", req.Content))
-	if err := s.validateCreateRequest(req); err != nil {
+	note, err := s.prepareNote(req)
+	if err != nil {
 		return nil, err
 	}
 
-	note := &models.Note{
-		Content: strings.TrimSpace(req.Content),
-	}
-
-	if err := s.repo.CreateNote(note); err != nil {
+	err = s.uow.Do(ctx, func(ctx context.Context, repos db.Repositories) error {
+		if err := repos.Notes.CreateNote(ctx, note); err != nil {
+			return err
+		}
+		return storeChunks(ctx, repos.Notes, note)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -35,13 +42,13 @@ func (s *NoteService) CreateNote(req *models.CreateNoteRequest) (*models.Note, e
 	return note, nil
 }
 
-func (s *NoteService) GetNoteByID(id int64) (*models.Note, error) {
+func (s *NoteService) GetNoteByID(ctx context.Context, id int64) (*models.Note, error) {
 	s.logger.Info("Attempting to retrieve note by ID", slog.Any("note_id", id))
 	if id <= 0 {
 		return nil, fmt.Errorf("invalid note ID: %d", id)
 	}
 
-	note, err := s.repo.GetNoteById(id)
+	note, err := s.repo.GetNoteById(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -50,9 +57,9 @@ func (s *NoteService) GetNoteByID(id int64) (*models.Note, error) {
 	return note, nil
 }
 
-func (s *NoteService) GetAllNotes() ([]*models.Note, error) {
+func (s *NoteService) GetAllNotes(ctx context.Context) ([]*models.Note, error) {
 	s.logger.Info("Attempting to retrieve all notes")
-	notes, err := s.repo.GetAllNotes()
+	notes, err := s.repo.GetAllNotes(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -61,45 +68,47 @@ func (s *NoteService) GetAllNotes() ([]*models.Note, error) {
 	return notes, nil
 }
 
-func (s *NoteService) UpdateNote(id int64, req *models.UpdateNoteRequest) (*models.Note, error) {
+func (s *NoteService) UpdateNote(ctx context.Context, id int64, req *models.UpdateNoteRequest) (*models.Note, error) {
 	s.logger.Info("Attempting to update note", slog.Any("note_id", id), slog.Any("updates", req))
 	if id <= 0 {
 		return nil, fmt.Errorf("invalid note ID: %d", id)
 	}
 
-	if err := s.validateUpdateRequest(req); err != nil {
+	updates, err := s.prepareUpdates(req)
+	if err != nil {
 		return nil, err
 	}
 
-	updates := make(map[string]any)
-
-	if req.Content != nil {
-		trimmedContent := strings.TrimSpace(*req.Content)
-		if trimmedContent == "" {
-			return nil, fmt.Errorf("content cannot be empty")
+	var note *models.Note
+	err = s.uow.Do(ctx, func(ctx context.Context, repos db.Repositories) error {
+		if err := repos.Notes.UpdateNote(ctx, id, updates); err != nil {
+			return err
 		}
-		updates["content"] = trimmedContent
-	}
-
-	if len(updates) == 0 {
-		return nil, fmt.Errorf("no valid updates provided")
-	}
-
-	if err := s.repo.UpdateNote(id, updates); err != nil {
+		var err error
+		note, err = repos.Notes.GetNoteById(ctx, id)
+		if err != nil {
+			return err
+		}
+		if _, ok := updates["content"]; ok {
+			return storeChunks(ctx, repos.Notes, note)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	s.logger.Info("Note updated successfully", slog.Any("note_id", id))
-	return s.repo.GetNoteById(id)
+	return note, nil
 }
 
-func (s *NoteService) DeleteNote(id int64) error {
+func (s *NoteService) DeleteNote(ctx context.Context, id int64) error {
 	s.logger.Info("Attempting to delete note", slog.Any("note_id", id))
 	if id <= 0 {
 		return fmt.Errorf("invalid note ID: %d", id)
 	}
 
-	err := s.repo.DeleteNote(id)
+	err := s.repo.DeleteNote(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -108,38 +117,128 @@ func (s *NoteService) DeleteNote(id int64) error {
 	return nil
 }
 
-func (s *NoteService) validateCreateRequest(req *models.CreateNoteRequest) error {
+// Ping reports whether the note repository can reach its database.
+func (s *NoteService) Ping(ctx context.Context) error {
+	return s.repo.Ping(ctx)
+}
+
+// prepareNote normalizes a create request and checks it against the content
+// policy, returning the note to store. Creating, importing and previewing
+// an import all go through it.
+func (s *NoteService) prepareNote(req *models.CreateNoteRequest) (*models.Note, error) {
 	if req == nil {
-		return fmt.Errorf("request cannot be nil")
+		return nil, fmt.Errorf("request cannot be nil")
 	}
-
-	content := strings.TrimSpace(req.Content)
-	if content == "" {
-		return fmt.Errorf("content is required")
+	if err := checkUTF8(req.Title, req.Content, req.Tags); err != nil {
+		return nil, err
 	}
 
-	//if len(content) > 255 {
-	//	return fmt.Errorf("content cannot exceed 255 characters")
-	//}
-
-	return nil
+	note := &models.Note{
+		Title:   normalizeLine(req.Title),
+		Content: normalizeText(req.Content),
+		Tags:    normalizeTags(req.Tags),
+	}
+	if err := s.policy.checkContent(note.Content); err != nil {
+		return nil, err
+	}
+	if err := validateTitle(note.Title); err != nil {
+		return nil, err
+	}
+	if err := validateTags(note.Tags); err != nil {
+		return nil, err
+	}
+	return note, nil
 }
 
-func (s *NoteService) validateUpdateRequest(req *models.UpdateNoteRequest) error {
+// prepareUpdates normalizes the fields of an update request and checks them
+// against the same rules as prepareNote, returning the updates to apply.
+func (s *NoteService) prepareUpdates(req *models.UpdateNoteRequest) (map[string]any, error) {
 	if req == nil {
-		return fmt.Errorf("request cannot be nil")
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+
+	if req.Title == nil && req.Content == nil && req.Tags == nil {
+		return nil, fmt.Errorf("at least one field must be provided for update")
+	}
+	var title, content string
+	var tags []string
+	if req.Title != nil {
+		title = *req.Title
+	}
+	if req.Content != nil {
+		content = *req.Content
+	}
+	if req.Tags != nil {
+		tags = *req.Tags
 	}
+	if err := checkUTF8(title, content, tags); err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]any)
 
-	if req.Content == nil {
-		return fmt.Errorf("at least one field must be provided for update")
+	if req.Title != nil {
+		title := normalizeLine(*req.Title)
+		if err := validateTitle(title); err != nil {
+			return nil, err
+		}
+		updates["title"] = title
 	}
 
 	if req.Content != nil {
-		content := strings.TrimSpace(*req.Content)
-		if len(content) > 255 {
-			return fmt.Errorf("content cannot exceed 255 characters")
+		content := normalizeText(*req.Content)
+		if err := s.policy.checkContent(content); err != nil {
+			return nil, err
+		}
+		updates["content"] = content
+	}
+
+	if req.Tags != nil {
+		tags := normalizeTags(*req.Tags)
+		if err := validateTags(tags); err != nil {
+			return nil, err
 		}
+		updates["tags"] = tags
 	}
 
+	return updates, nil
+}
+
+const (
+	maxTitleLength = 255
+	maxTags        = 32
+	maxTagLength   = 64
+)
+
+func validateTitle(title string) error {
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		return fmt.Errorf("title cannot exceed %d characters", maxTitleLength)
+	}
 	return nil
 }
+
+// validateTags checks tags that normalizeTags has already cleaned.
+func validateTags(tags []string) error {
+	if len(tags) > maxTags {
+		return fmt.Errorf("a note cannot have more than %d tags", maxTags)
+	}
+	for _, tag := range tags {
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return fmt.Errorf("tag %q exceeds %d characters", tag, maxTagLength)
+		}
+	}
+	return nil
+}
+
+// normalizeTags normalizes tags like titles and drops empty and duplicate
+// ones, keeping order.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = normalizeLine(tag)
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
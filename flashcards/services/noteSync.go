@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go-ai-eng-flashcards/db"
+	"go-ai-eng-flashcards/models"
+)
+
+// NotesBySourcePrefix returns the notes whose source path starts with
+// prefix, soft-deleted ones included, keyed by source path.
+func (s *NoteService) NotesBySourcePrefix(ctx context.Context, prefix string) (map[string]*models.Note, error) {
+	s.logger.Info("Attempting to list notes by source path prefix", slog.String("prefix", prefix))
+	if prefix == "" {
+		return nil, fmt.Errorf("source path prefix cannot be empty")
+	}
+
+	notes, err := s.repo.ListNotesBySourcePrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	bySource := make(map[string]*models.Note, len(notes))
+	for _, note := range notes {
+		bySource[note.SourcePath] = note
+	}
+	s.logger.Info("Notes listed successfully", slog.String("prefix", prefix), slog.Int("count", len(notes)))
+	return bySource, nil
+}
+
+// SoftDeleteNotes marks the notes with the given source paths as deleted, in
+// one unit of work, and returns how many it marked. Paths with no stored
+// note, or whose note is already deleted, are skipped. Soft-deleted notes
+// disappear from every read, and importing their source path again
+// restores them.
+func (s *NoteService) SoftDeleteNotes(ctx context.Context, sourcePaths []string) (int, error) {
+	s.logger.Info("Attempting to soft-delete notes", slog.Int("count", len(sourcePaths)))
+
+	deleted := 0
+	err := s.uow.Do(ctx, func(ctx context.Context, repos db.Repositories) error {
+		deleted = 0
+		now := time.Now()
+		for _, sourcePath := range sourcePaths {
+			note, err := repos.Notes.GetNoteBySourcePath(ctx, sourcePath)
+			if err != nil {
+				return err
+			}
+			if note == nil || note.DeletedAt != nil {
+				continue
+			}
+			if err := repos.Notes.UpdateNote(ctx, int64(note.ID), map[string]any{"deleted_at": now}); err != nil {
+				return fmt.Errorf("failed to soft-delete %s: %w", sourcePath, err)
+			}
+			deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.Info("Notes soft-deleted successfully", slog.Int("deleted", deleted))
+	return deleted, nil
+}
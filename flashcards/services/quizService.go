@@ -5,10 +5,19 @@ import (
 	"fmt"
 	"go-ai-eng-flashcards/models"
 	"log/slog"
+	"math/rand/v2"
+	"slices"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go-ai-eng-flashcards/metrics"
+	"go-ai-eng-flashcards/tracing"
 
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/googleai"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -24,8 +33,36 @@ Here's how you should behave:
 7.  Maintain a positive and encouraging tone throughout the conversation.
 8.  Do not go off-topic. All questions and answers should be related to the provided notes.`
 	userPromptTemplate = "Here are my notes:\n\n%s\n\nHere is our conversation so far:\n\n%s"
+
+	// quizTurnOperation labels LLM metrics recorded for quiz turns.
+	quizTurnOperation = "quiz_turn"
+	// pingOperation labels LLM metrics recorded for readiness checks.
+	pingOperation = "ping"
+
+	// quizPassageBudget caps the characters of note passages in one quiz
+	// prompt. When the notes are longer, a selection of passages is sent.
+	quizPassageBudget = 24000
+	// quizContextMessages is how many of the latest messages passages are
+	// matched against, so the quiz keeps the passage being discussed.
+	quizContextMessages = 2
 )
 
+// passage is one chunk of a note as shown to the quiz.
+type passage struct {
+	title   string
+	heading []string
+	content string
+}
+
+func (p passage) String() string {
+	label := strings.Join(append([]string{p.title}, p.heading...), " > ")
+	label = strings.TrimPrefix(label, " > ")
+	if label == "" {
+		return p.content
+	}
+	return label + ":\n" + p.content
+}
+
 // QuizService handles the business logic for quiz generation.
 type QuizService struct {
 	llm         *googleai.GoogleAI
@@ -46,9 +83,9 @@ func NewQuizService(apiKey string, noteService *NoteService, logger *slog.Logger
 }
 
 // GenerateQuizTurn adds a new, LLM-generated assistant message to a conversation history.
-func (s *QuizService) GenerateQuizTurn(currentMessages []models.Message) []models.Message {
+func (s *QuizService) GenerateQuizTurn(ctx context.Context, currentMessages []models.Message) []models.Message {
 	s.logger.Info("Generating quiz turn")
-	allNotes, err := s.noteService.GetAllNotes()
+	allNotes, chunks, err := s.noteService.GetAllNotesWithChunks(ctx)
 	if err != nil {
 		s.logger.Error("Error fetching notes for quiz generation", slog.Any("error", err))
 		assistantMessage := models.Message{
@@ -58,10 +95,18 @@ func (s *QuizService) GenerateQuizTurn(currentMessages []models.Message) []model
 		return append(currentMessages, assistantMessage)
 	}
 
-	var noteBuilder strings.Builder
+	var all []passage
 	for _, note := range allNotes {
-		noteBuilder.WriteString(note.Content)
-		noteBuilder.WriteString("\n")
+		for _, chunk := range chunks[note.ID] {
+			all = append(all, passage{title: note.Title, heading: chunk.Heading, content: chunk.Content})
+		}
+	}
+	passages := selectPassages(all, currentMessages, quizPassageBudget)
+
+	var noteBuilder strings.Builder
+	for _, p := range passages {
+		noteBuilder.WriteString(p.String())
+		noteBuilder.WriteString("\n\n")
 	}
 
 	var convBuilder strings.Builder
@@ -76,9 +121,20 @@ func (s *QuizService) GenerateQuizTurn(currentMessages []models.Message) []model
 		llms.TextParts(llms.ChatMessageTypeHuman, userPrompt),
 	}
 
-	ctx := context.Background()
+	ctx, span := tracing.StartSpan(ctx, "llm.GenerateContent",
+		attribute.String("gen_ai.system", "gemini"),
+		attribute.String("gen_ai.operation.name", quizTurnOperation),
+		attribute.Int("gen_ai.prompt.chars", len(systemPrompt)+len(userPrompt)),
+		attribute.Int("quiz.notes", len(allNotes)),
+		attribute.Int("quiz.passages", len(passages)),
+		attribute.Int("quiz.passages.total", len(all)),
+		attribute.Int("quiz.messages", len(currentMessages)),
+	)
+	start := time.Now()
 	completion, err := s.llm.GenerateContent(ctx, messages, llms.WithTemperature(0.8))
+	metrics.ObserveLLMCall(quizTurnOperation, start, err)
 	if err != nil {
+		tracing.EndSpan(span, err)
 		s.logger.Error("Error generating content from LLM", slog.Any("error", err))
 		// Fallback to a generic error message
 		assistantMessage := models.Message{
@@ -89,8 +145,21 @@ func (s *QuizService) GenerateQuizTurn(currentMessages []models.Message) []model
 	}
 
 	generatedContent := "Sorry, I couldn't generate a question."
+	if len(completion.Choices) > 0 {
+		promptTokens, completionTokens := tokenUsage(completion.Choices[0].GenerationInfo)
+		metrics.ObserveLLMTokens(quizTurnOperation, promptTokens, completionTokens)
+		span.SetAttributes(
+			attribute.Int("gen_ai.usage.input_tokens", promptTokens),
+			attribute.Int("gen_ai.usage.output_tokens", completionTokens),
+		)
+	}
+	tracing.EndSpan(span, nil)
 	if len(completion.Choices) > 0 && len(completion.Choices[0].Content) > 0 {
-		generatedContent = completion.Choices[0].Content
+		// Replies are sent back as JSON and stored by clients, so a broken
+		// byte sequence from the provider must not get through.
+		generatedContent = strings.ToValidUTF8(completion.Choices[0].Content, "\uFFFD")
+	} else {
+		metrics.ObserveLLMEmptyResponse(quizTurnOperation)
 	}
 
 	assistantMessage := models.Message{
@@ -101,3 +170,89 @@ func (s *QuizService) GenerateQuizTurn(currentMessages []models.Message) []model
 	s.logger.Info("Quiz turn generated successfully")
 	return append(currentMessages, assistantMessage)
 }
+
+// selectPassages returns the passages that fit in budget characters, in
+// their original order. When they do not all fit, passages sharing the most
+// words with the latest messages are picked first, so the passage a question
+// came from stays in the prompt while its answer is checked, and the rest
+// are filled at random so later questions cover all the notes.
+func selectPassages(passages []passage, messages []models.Message, budget int) []passage {
+	total := 0
+	for _, p := range passages {
+		total += len(p.String())
+	}
+	if total <= budget {
+		return passages
+	}
+
+	var recent strings.Builder
+	for _, m := range messages[max(0, len(messages)-quizContextMessages):] {
+		recent.WriteString(m.Content)
+		recent.WriteString(" ")
+	}
+	terms := words(recent.String())
+
+	scores := make([]int, len(passages))
+	for i, p := range passages {
+		for word := range words(p.String()) {
+			if terms[word] {
+				scores[i]++
+			}
+		}
+	}
+	order := rand.Perm(len(passages))
+	slices.SortStableFunc(order, func(a, b int) int { return scores[b] - scores[a] })
+
+	chosen := make([]bool, len(passages))
+	used := 0
+	for _, i := range order {
+		if size := len(passages[i].String()); used+size <= budget {
+			chosen[i] = true
+			used += size
+		}
+	}
+	selected := make([]passage, 0, len(passages))
+	for i, p := range passages {
+		if chosen[i] {
+			selected = append(selected, p)
+		}
+	}
+	return selected
+}
+
+// words returns the distinct lowercase words of text that are long enough
+// to tell passages apart.
+func words(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if utf8.RuneCountInString(word) >= 4 {
+			set[word] = true
+		}
+	}
+	return set
+}
+
+// Ping performs the cheapest possible round trip to the LLM provider, a
+// single-token completion, to confirm the API key and network path work.
+func (s *QuizService) Ping(ctx context.Context) error {
+	start := time.Now()
+	_, err := s.llm.GenerateContent(ctx,
+		[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "ping")},
+		llms.WithMaxTokens(1),
+	)
+	metrics.ObserveLLMCall(pingOperation, start, err)
+	if err != nil {
+		s.logger.Error("LLM provider check failed", slog.Any("error", err))
+		return fmt.Errorf("failed to reach LLM provider: %w", err)
+	}
+	return nil
+}
+
+// tokenUsage extracts the prompt and completion token counts the provider reports in a choice's generation info.
+func tokenUsage(info map[string]any) (int, int) {
+	promptTokens, _ := info["PromptTokens"].(int32)
+	completionTokens, _ := info["CompletionTokens"].(int32)
+	return int(promptTokens), int(completionTokens)
+}
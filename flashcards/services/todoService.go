@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -10,13 +11,14 @@ import (
 
 type TodoService struct {
 	repo db.TodoRepository
+	uow  db.UnitOfWork
 }
 
-func NewTodoService(repo db.TodoRepository) *TodoService {
-	return &TodoService{repo: repo}
+func NewTodoService(repo db.TodoRepository, uow db.UnitOfWork) *TodoService {
+	return &TodoService{repo: repo, uow: uow}
 }
 
-func (s *TodoService) CreateTodo(req *models.CreateTodoRequest) (*models.Todo, error) {
+func (s *TodoService) CreateTodo(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
 	if err := s.validateCreateRequest(req); err != nil {
 		return nil, err
 	}
@@ -27,19 +29,19 @@ func (s *TodoService) CreateTodo(req *models.CreateTodoRequest) (*models.Todo, e
 		Completed:   false,
 	}
 
-	if err := s.repo.CreateTodo(todo); err != nil {
+	if err := s.repo.CreateTodo(ctx, todo); err != nil {
 		return nil, fmt.Errorf("failed to create todo: %w", err)
 	}
 
 	return todo, nil
 }
 
-func (s *TodoService) GetTodoByID(id int) (*models.Todo, error) {
+func (s *TodoService) GetTodoByID(ctx context.Context, id int) (*models.Todo, error) {
 	if id <= 0 {
 		return nil, fmt.Errorf("invalid todo ID: %d", id)
 	}
 
-	todo, err := s.repo.GetTodoByID(id)
+	todo, err := s.repo.GetTodoByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -47,8 +49,8 @@ func (s *TodoService) GetTodoByID(id int) (*models.Todo, error) {
 	return todo, nil
 }
 
-func (s *TodoService) GetAllTodos() ([]*models.Todo, error) {
-	todos, err := s.repo.GetAllTodos()
+func (s *TodoService) GetAllTodos(ctx context.Context) ([]*models.Todo, error) {
+	todos, err := s.repo.GetAllTodos(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get todos: %w", err)
 	}
@@ -56,7 +58,7 @@ func (s *TodoService) GetAllTodos() ([]*models.Todo, error) {
 	return todos, nil
 }
 
-func (s *TodoService) UpdateTodo(id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
+func (s *TodoService) UpdateTodo(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
 	if id <= 0 {
 		return nil, fmt.Errorf("invalid todo ID: %d", id)
 	}
@@ -87,19 +89,33 @@ func (s *TodoService) UpdateTodo(id int, req *models.UpdateTodoRequest) (*models
 		return nil, fmt.Errorf("no valid updates provided")
 	}
 
-	if err := s.repo.UpdateTodo(id, updates); err != nil {
+	var todo *models.Todo
+	err := s.uow.Do(ctx, func(ctx context.Context, repos db.Repositories) error {
+		if err := repos.Todos.UpdateTodo(ctx, id, updates); err != nil {
+			return err
+		}
+		var err error
+		todo, err = repos.Todos.GetTodoByID(ctx, id)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return s.repo.GetTodoByID(id)
+	return todo, nil
 }
 
-func (s *TodoService) DeleteTodo(id int) error {
+func (s *TodoService) DeleteTodo(ctx context.Context, id int) error {
 	if id <= 0 {
 		return fmt.Errorf("invalid todo ID: %d", id)
 	}
 
-	return s.repo.DeleteTodo(id)
+	return s.repo.DeleteTodo(ctx, id)
+}
+
+// Ping reports whether the todo repository can reach its database.
+func (s *TodoService) Ping(ctx context.Context) error {
+	return s.repo.Ping(ctx)
 }
 
 func (s *TodoService) validateCreateRequest(req *models.CreateTodoRequest) error {
@@ -0,0 +1,5 @@
+ALTER TABLE flashcards.notes
+    ADD COLUMN IF NOT EXISTS title TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_notes_tags ON flashcards.notes USING GIN (tags);
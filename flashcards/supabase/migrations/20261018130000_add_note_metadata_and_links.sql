@@ -0,0 +1,17 @@
+-- Imported notes keep their source's metadata (e.g. Obsidian frontmatter) and
+-- a stable source path, so importing the same files again updates them.
+ALTER TABLE flashcards.notes
+    ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS source_path TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notes_source_path
+    ON flashcards.notes (source_path)
+    WHERE source_path IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS flashcards.note_links (
+    from_note_id INTEGER NOT NULL REFERENCES flashcards.notes (id) ON DELETE CASCADE,
+    to_note_id INTEGER NOT NULL REFERENCES flashcards.notes (id) ON DELETE CASCADE,
+    PRIMARY KEY (from_note_id, to_note_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_links_to_note_id ON flashcards.note_links (to_note_id);
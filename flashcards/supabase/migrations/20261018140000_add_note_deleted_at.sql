@@ -0,0 +1,8 @@
+-- Notes synced from a watched directory are soft-deleted when their file is
+-- removed, so restoring the file brings back the same note and its ID.
+ALTER TABLE flashcards.notes
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_notes_live_created_at
+    ON flashcards.notes (created_at DESC)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,11 @@
+-- Long notes are split into ordered passages so the quiz can work on focused
+-- text instead of whole notes. Chunks are derived from the note's content and
+-- rewritten whenever it changes; notes stored before this migration are
+-- chunked when read until they are next saved.
+CREATE TABLE IF NOT EXISTS flashcards.note_chunks (
+    note_id INTEGER NOT NULL REFERENCES flashcards.notes (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    heading TEXT[] NOT NULL DEFAULT '{}',
+    content TEXT NOT NULL,
+    PRIMARY KEY (note_id, position)
+);
@@ -0,0 +1,118 @@
+package tui
+
+import (
+	"strings"
+
+	"go-ai-eng-flashcards/models"
+)
+
+// Grade is how well a card was recalled.
+type Grade int
+
+const (
+	GradeAgain Grade = iota
+	GradeHard
+	GradeGood
+)
+
+// requeueGap is how many cards are shown before a card graded Again or Hard
+// comes back in the same session.
+var requeueGap = map[Grade]int{
+	GradeAgain: 2,
+	GradeHard:  5,
+}
+
+// Card is one note split into the side that is shown first and the answer.
+type Card struct {
+	NoteID int
+	Front  string
+	Back   string
+}
+
+// CardFromNote uses a note's title as the front of the card and its content
+// as the back. Untitled notes use their first line as the front and the rest
+// as the back; single-line untitled notes have no back.
+func CardFromNote(note *models.Note) Card {
+	content := strings.TrimSpace(note.Content)
+	if title := strings.TrimSpace(note.Title); title != "" {
+		return Card{NoteID: note.ID, Front: title, Back: content}
+	}
+	front, back, _ := strings.Cut(content, "\n")
+	return Card{
+		NoteID: note.ID,
+		Front:  strings.TrimSpace(front),
+		Back:   strings.TrimSpace(back),
+	}
+}
+
+// Deck is the queue of cards still due in a study session. Cards graded Good
+// leave the queue; Again and Hard put them back a few places later so they
+// are seen again before the session ends.
+type Deck struct {
+	queue    []Card
+	total    int
+	reviewed int
+	lapses   int
+}
+
+// NewDeck creates a session over notes, in the order given.
+func NewDeck(notes []*models.Note) *Deck {
+	d := &Deck{}
+	for _, note := range notes {
+		if strings.TrimSpace(note.Content) == "" {
+			continue
+		}
+		d.queue = append(d.queue, CardFromNote(note))
+	}
+	d.total = len(d.queue)
+	return d
+}
+
+// Current returns the card being studied and false once the deck is empty.
+func (d *Deck) Current() (Card, bool) {
+	if len(d.queue) == 0 {
+		return Card{}, false
+	}
+	return d.queue[0], true
+}
+
+// Grade records the result for the current card and moves to the next one.
+func (d *Deck) Grade(g Grade) {
+	if len(d.queue) == 0 {
+		return
+	}
+	card := d.queue[0]
+	d.queue = d.queue[1:]
+	d.reviewed++
+
+	gap, requeue := requeueGap[g]
+	if !requeue {
+		return
+	}
+	if g == GradeAgain {
+		d.lapses++
+	}
+	at := min(gap, len(d.queue))
+	d.queue = append(d.queue[:at], append([]Card{card}, d.queue[at:]...)...)
+}
+
+// Remaining is the number of cards still due, counting requeued cards once each.
+func (d *Deck) Remaining() int {
+	seen := make(map[int]bool, len(d.queue))
+	for _, card := range d.queue {
+		seen[card.NoteID] = true
+	}
+	return len(seen)
+}
+
+// Progress is the fraction of the session's cards that are done.
+func (d *Deck) Progress() float64 {
+	if d.total == 0 {
+		return 1
+	}
+	return float64(d.total-d.Remaining()) / float64(d.total)
+}
+
+func (d *Deck) Total() int    { return d.total }
+func (d *Deck) Reviewed() int { return d.reviewed }
+func (d *Deck) Lapses() int   { return d.lapses }
@@ -0,0 +1,321 @@
+// Package tui is a full-screen terminal UI for study sessions: it turns notes
+// into flashcards, lets the user flip and grade them, and hosts a quiz chat
+// with the LLM. It only depends on a Source, so it runs the same against the
+// HTTP API or directly on the service layer.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go-ai-eng-flashcards/models"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Source supplies the notes to study and answers quiz turns.
+type Source interface {
+	ListNotes(ctx context.Context) ([]*models.Note, error)
+	QuizTurn(ctx context.Context, messages []models.Message) ([]models.Message, error)
+}
+
+type mode int
+
+const (
+	modeLoading mode = iota
+	modeStudy
+	modeChat
+	modeDone
+)
+
+type notesLoadedMsg struct {
+	notes []*models.Note
+	err   error
+}
+
+type quizTurnMsg struct {
+	messages []models.Message
+	err      error
+}
+
+var (
+	titleStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
+	cardStyle  = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("63")).Padding(1, 2)
+	backStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+	helpStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	roleStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+)
+
+// Model is the bubbletea model for a study session.
+type Model struct {
+	ctx    context.Context
+	source Source
+
+	mode     mode
+	prevMode mode
+	deck     *Deck
+	flipped  bool
+	err      error
+
+	messages []models.Message
+	waiting  bool
+	input    textinput.Model
+	chat     viewport.Model
+	progress progress.Model
+
+	width, height int
+}
+
+// New creates a study session that loads its cards from source.
+func New(ctx context.Context, source Source) Model {
+	input := textinput.New()
+	input.Placeholder = "Type your answer and press enter"
+	input.CharLimit = 2000
+
+	return Model{
+		ctx:      ctx,
+		source:   source,
+		input:    input,
+		chat:     viewport.New(80, 20),
+		progress: progress.New(progress.WithDefaultGradient()),
+		width:    80,
+		height:   24,
+	}
+}
+
+// Run starts the UI on the terminal and blocks until the user quits.
+func Run(ctx context.Context, source Source) error {
+	_, err := tea.NewProgram(New(ctx, source), tea.WithAltScreen(), tea.WithContext(ctx)).Run()
+	return err
+}
+
+func (m Model) Init() tea.Cmd {
+	return m.loadNotes
+}
+
+func (m Model) loadNotes() tea.Msg {
+	notes, err := m.source.ListNotes(m.ctx)
+	return notesLoadedMsg{notes: notes, err: err}
+}
+
+func (m Model) quizTurn(messages []models.Message) tea.Cmd {
+	return func() tea.Msg {
+		reply, err := m.source.QuizTurn(m.ctx, messages)
+		return quizTurnMsg{messages: reply, err: err}
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.progress.Width = max(msg.Width-4, 10)
+		m.input.Width = max(msg.Width-6, 10)
+		m.chat.Width = msg.Width
+		m.chat.Height = max(msg.Height-6, 3)
+		m.refreshChat()
+		return m, nil
+
+	case notesLoadedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.mode = modeDone
+			return m, nil
+		}
+		m.deck = NewDeck(msg.notes)
+		m.mode = modeStudy
+		if _, ok := m.deck.Current(); !ok {
+			m.mode = modeDone
+		}
+		return m, nil
+
+	case quizTurnMsg:
+		m.waiting = false
+		if msg.err != nil {
+			m.err = msg.err
+		} else {
+			m.err = nil
+			m.messages = msg.messages
+		}
+		m.refreshChat()
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			return m, tea.Quit
+		}
+		switch m.mode {
+		case modeStudy:
+			return m.updateStudy(msg)
+		case modeChat:
+			return m.updateChat(msg)
+		default:
+			if msg.String() == "q" || msg.Type == tea.KeyEsc {
+				return m, tea.Quit
+			}
+			if msg.Type == tea.KeyTab && m.deck != nil {
+				return m.openChat()
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m Model) updateStudy(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc":
+		return m, tea.Quit
+	case " ", "enter", "f":
+		m.flipped = !m.flipped
+	case "1", "2", "3":
+		if !m.flipped {
+			return m, nil
+		}
+		m.deck.Grade(Grade(msg.String()[0] - '1'))
+		m.flipped = false
+		if _, ok := m.deck.Current(); !ok {
+			m.mode = modeDone
+		}
+	case "tab":
+		return m.openChat()
+	}
+	return m, nil
+}
+
+func (m Model) openChat() (tea.Model, tea.Cmd) {
+	m.prevMode = m.mode
+	m.mode = modeChat
+	m.refreshChat()
+	cmds := []tea.Cmd{m.input.Focus()}
+	if len(m.messages) == 0 && !m.waiting {
+		m.waiting = true
+		cmds = append(cmds, m.quizTurn(nil))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) updateChat(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyTab:
+		m.input.Blur()
+		m.mode = m.prevMode
+		return m, nil
+	case tea.KeyEnter:
+		answer := strings.TrimSpace(m.input.Value())
+		if answer == "" || m.waiting {
+			return m, nil
+		}
+		m.input.Reset()
+		m.messages = append(m.messages, models.Message{Role: "user", Content: answer})
+		m.waiting = true
+		m.refreshChat()
+		return m, m.quizTurn(m.messages)
+	case tea.KeyPgUp, tea.KeyPgDown, tea.KeyUp, tea.KeyDown:
+		var cmd tea.Cmd
+		m.chat, cmd = m.chat.Update(msg)
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// refreshChat re-renders the transcript into the viewport and scrolls to the end.
+func (m *Model) refreshChat() {
+	wrap := lipgloss.NewStyle().Width(max(m.chat.Width-2, 10))
+	var b strings.Builder
+	for _, message := range m.messages {
+		b.WriteString(roleStyle.Render(message.Role))
+		b.WriteString("\n")
+		b.WriteString(wrap.Render(strings.TrimSpace(message.Content)))
+		b.WriteString("\n\n")
+	}
+	if m.waiting {
+		b.WriteString(helpStyle.Render("thinking…"))
+	}
+	m.chat.SetContent(b.String())
+	m.chat.GotoBottom()
+}
+
+func (m Model) View() string {
+	switch m.mode {
+	case modeLoading:
+		return "Loading notes…\n"
+	case modeChat:
+		return m.viewChat()
+	case modeDone:
+		return m.viewDone()
+	default:
+		return m.viewStudy()
+	}
+}
+
+func (m Model) header() string {
+	return titleStyle.Render(fmt.Sprintf("Flashcards · %d of %d due · %d reviewed · %d lapses",
+		m.deck.Remaining(), m.deck.Total(), m.deck.Reviewed(), m.deck.Lapses()))
+}
+
+func (m Model) viewStudy() string {
+	card, _ := m.deck.Current()
+	width := max(m.width-4, 20)
+
+	body := card.Front
+	if m.flipped {
+		back := card.Back
+		if back == "" {
+			back = "(no answer on this card)"
+		}
+		body += "\n\n" + backStyle.Render(back)
+	}
+
+	help := "space flip · tab quiz chat · q quit"
+	if m.flipped {
+		help = "1 again · 2 hard · 3 good · space flip back · tab quiz chat · q quit"
+	}
+
+	return strings.Join([]string{
+		m.header(),
+		m.progress.ViewAs(m.deck.Progress()),
+		"",
+		cardStyle.Width(width).Render(body),
+		"",
+		helpStyle.Render(help),
+	}, "\n")
+}
+
+func (m Model) viewChat() string {
+	status := helpStyle.Render("enter send · ↑/↓ scroll · esc back to cards · ctrl+c quit")
+	if m.err != nil {
+		status = errorStyle.Render(m.err.Error())
+	}
+	return strings.Join([]string{
+		titleStyle.Render("Quiz chat"),
+		m.chat.View(),
+		m.input.View(),
+		status,
+	}, "\n")
+}
+
+func (m Model) viewDone() string {
+	if m.err != nil {
+		return errorStyle.Render("Could not load notes: "+m.err.Error()) + "\n\n" + helpStyle.Render("q quit") + "\n"
+	}
+	if m.deck == nil || m.deck.Total() == 0 {
+		return "No notes to study yet. Add some with `flashcards notes add`.\n\n" + helpStyle.Render("q quit") + "\n"
+	}
+	return strings.Join([]string{
+		m.header(),
+		m.progress.ViewAs(1),
+		"",
+		fmt.Sprintf("Session complete: %d cards, %d reviews, %d lapses.", m.deck.Total(), m.deck.Reviewed(), m.deck.Lapses()),
+		"",
+		helpStyle.Render("tab quiz chat · q quit"),
+	}, "\n") + "\n"
+}
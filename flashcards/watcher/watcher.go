@@ -0,0 +1,358 @@
+// Package watcher keeps notes in sync with a directory of Markdown files, so
+// notes can be written in any editor. Every .md file under the directory is
+// one note; new files create notes, changed files update them, and removed
+// files soft-delete them.
+package watcher
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go-ai-eng-flashcards/importer"
+	"go-ai-eng-flashcards/models"
+	"go-ai-eng-flashcards/services"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// SourcePrefix starts the SourcePath of every note synced from the watched
+// directory; the rest is the file's path inside it.
+const SourcePrefix = "watch:"
+
+// MetadataKey is the metadata key under which a synced note keeps the
+// SHA-256 of the file it was last read from, as {"sha256": "<hex>"}. A file
+// whose hash matches its note is not imported again.
+const MetadataKey = "watch"
+
+// Watcher syncs the notes of one directory.
+type Watcher struct {
+	dir      string
+	debounce time.Duration
+	notes    *services.NoteService
+	logger   *slog.Logger
+
+	// failed holds the stamp of each file that last failed to read, parse
+	// or validate, so it is not retried until it changes again.
+	failed map[string]stamp
+	// hashes caches the hash of each file by the stamp it was read at, so
+	// unchanged files are not read on every sync.
+	hashes map[string]hashedFile
+}
+
+// stamp identifies one version of a file as the filesystem reports it. It
+// is only compared with earlier stamps of the same file, never with the
+// database's timestamps.
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// file is a Markdown file found in the watched directory.
+type file struct {
+	path string
+	stamp
+}
+
+type hashedFile struct {
+	stamp
+	sum string
+}
+
+// New creates a Watcher for dir. Changes are synced once no event has
+// arrived for the debounce interval, so an editor's burst of writes is one
+// sync.
+func New(dir string, debounce time.Duration, notes *services.NoteService, logger *slog.Logger) (*Watcher, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve watched directory: %w", err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open watched directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("watched path %s is not a directory", abs)
+	}
+	return &Watcher{dir: abs, debounce: debounce, notes: notes, logger: logger, failed: make(map[string]stamp), hashes: make(map[string]hashedFile)}, nil
+}
+
+// Run syncs the directory, then watches it and syncs again after every
+// burst of changes, until ctx is cancelled. Each sync compares the whole
+// directory with the stored notes, so events that were missed or arrived
+// while the server was down are caught up on the next one.
+func (w *Watcher) Run(ctx context.Context) error {
+	w.logger.Info("Starting notes watcher", slog.String("dir", w.dir), slog.Duration("debounce", w.debounce))
+
+	events, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer events.Close()
+	if err := w.watchTree(events, w.dir); err != nil {
+		return err
+	}
+
+	w.sync(ctx)
+
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("Notes watcher stopped", slog.String("dir", w.dir))
+			return nil
+		case event, ok := <-events.Events:
+			if !ok {
+				return nil
+			}
+			if hidden(w.dir, event.Name) {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := w.watchTree(events, event.Name); err != nil {
+						w.logger.Error("Failed to watch new directory", slog.String("path", event.Name), slog.Any("error", err))
+					}
+				}
+			}
+			timer.Reset(w.debounce)
+		case err, ok := <-events.Errors:
+			if !ok {
+				return nil
+			}
+			w.logger.Error("File watcher error", slog.Any("error", err))
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				timer.Reset(w.debounce)
+			}
+		case <-timer.C:
+			w.sync(ctx)
+		}
+	}
+}
+
+// watchTree adds root and every directory below it to the watcher; fsnotify
+// does not watch recursively.
+func (w *Watcher) watchTree(events *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if path != root && hidden(w.dir, path) {
+			return filepath.SkipDir
+		}
+		if err := events.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// sync brings the stored notes in line with the directory. Files that are
+// new, whose contents differ from those their note was last synced from, or
+// whose note was soft-deleted are imported; notes whose file is gone are
+// soft-deleted. Files over importer.MaxFileSize are failures, so their
+// notes are left as they are. Errors are logged, and the next sync tries
+// again.
+func (w *Watcher) sync(ctx context.Context) {
+	w.logger.Info("Attempting to sync watched notes", slog.String("dir", w.dir))
+
+	files, err := w.scan()
+	if err != nil {
+		// Without a full listing, missing files cannot be told from deleted ones.
+		w.logger.Error("Failed to scan watched directory", slog.String("dir", w.dir), slog.Any("error", err))
+		return
+	}
+	stored, err := w.notes.NotesBySourcePrefix(ctx, SourcePrefix)
+	if err != nil {
+		w.logger.Error("Failed to list synced notes", slog.Any("error", err))
+		return
+	}
+
+	failed := make(map[string]stamp)
+	hashes := make(map[string]hashedFile)
+	var (
+		changed []importer.Note
+		// names[i] is the file changed[i] was read from.
+		names []string
+	)
+	for name, f := range files {
+		if last, ok := w.failed[name]; ok && last.Equal(f.stamp) {
+			failed[name] = last
+			continue
+		}
+		if f.size > importer.MaxFileSize {
+			w.logger.Error("Watched note is too large to import", slog.String("file", name), slog.Int64("size", f.size))
+			failed[name] = f.stamp
+			continue
+		}
+
+		note := stored[SourcePrefix+name]
+		cached, ok := w.hashes[name]
+		if ok && cached.Equal(f.stamp) {
+			hashes[name] = cached
+			if synced(note, cached.sum) {
+				continue
+			}
+		}
+
+		data, err := os.ReadFile(f.path)
+		if errors.Is(err, fs.ErrNotExist) {
+			// Removed since the scan; the next sync sees it gone.
+			continue
+		}
+		if err != nil {
+			w.logger.Error("Failed to read watched note", slog.String("file", name), slog.Any("error", err))
+			failed[name] = f.stamp
+			continue
+		}
+		sum := sha256.Sum256(data)
+		hashes[name] = hashedFile{stamp: f.stamp, sum: hex.EncodeToString(sum[:])}
+		if synced(note, hashes[name].sum) {
+			continue
+		}
+
+		parsed, err := parseNote(name, data, hashes[name].sum)
+		if err != nil {
+			w.logger.Error("Failed to parse watched note", slog.String("file", name), slog.Any("error", err))
+			failed[name] = f.stamp
+			continue
+		}
+		changed = append(changed, parsed)
+		names = append(names, name)
+	}
+	w.hashes = hashes
+
+	var result *models.ImportResult
+	if len(changed) > 0 {
+		result, err = w.notes.ImportNotes(ctx, changed)
+		if err != nil {
+			w.logger.Error("Failed to import watched notes", slog.Any("error", err))
+			return
+		}
+		// result.Items[i] is the item for changed[i].
+		for i, item := range result.Items {
+			if item.Status == models.ImportFailed {
+				w.logger.Error("Watched note was not imported", slog.String("file", names[i]), slog.String("error", item.Error))
+				failed[names[i]] = files[names[i]].stamp
+			}
+		}
+	}
+	w.failed = failed
+
+	var removed []string
+	for sourcePath, note := range stored {
+		if _, ok := files[strings.TrimPrefix(sourcePath, SourcePrefix)]; !ok && note.DeletedAt == nil {
+			removed = append(removed, sourcePath)
+		}
+	}
+	deleted := 0
+	if len(removed) > 0 {
+		deleted, err = w.notes.SoftDeleteNotes(ctx, removed)
+		if err != nil {
+			w.logger.Error("Failed to soft-delete removed notes", slog.Any("error", err))
+			return
+		}
+	}
+
+	attrs := []any{slog.Int("files", len(files)), slog.Int("deleted", deleted)}
+	if result != nil {
+		attrs = append(attrs, slog.Int("created", result.Created), slog.Int("updated", result.Updated), slog.Int("failed", result.Failed))
+	}
+	w.logger.Info("Watched notes synced successfully", attrs...)
+}
+
+// scan returns the Markdown files of the directory by their slash-separated
+// path inside it. Hidden files and folders are skipped.
+func (w *Watcher) scan() (map[string]file, error) {
+	files := make(map[string]file)
+	err := filepath.WalkDir(w.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == w.dir {
+			return nil
+		}
+		if hidden(w.dir, path) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || !importer.IsMarkdown(entry.Name()) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			// Removed while scanning; the next sync sees it gone.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(w.dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = file{path: path, stamp: stamp{modTime: info.ModTime(), size: info.Size()}}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// Equal reports whether s and other are the same version of a file.
+func (s stamp) Equal(other stamp) bool {
+	return s.modTime.Equal(other.modTime) && s.size == other.size
+}
+
+// synced reports whether note is live and was last synced from a file with
+// the hash sum. Edits made through the API keep the hash, so they stay until
+// the file changes.
+func synced(note *models.Note, sum string) bool {
+	if note == nil || note.DeletedAt != nil {
+		return false
+	}
+	meta, _ := note.Metadata[MetadataKey].(map[string]any)
+	return meta["sha256"] == sum
+}
+
+// parseNote parses data as the note synced from name, whose hash is sum.
+func parseNote(name string, data []byte, sum string) (importer.Note, error) {
+	note, err := importer.ParseMarkdownNote(importer.File{Name: name, Data: data})
+	if err != nil {
+		return importer.Note{}, err
+	}
+	note.SourcePath = SourcePrefix + name
+	note.Metadata[MetadataKey] = map[string]any{"sha256": sum}
+	return note, nil
+}
+
+// hidden reports whether any part of path below root starts with a dot, as
+// editors' swap files and folders such as .git and .obsidian do.
+func hidden(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if strings.HasPrefix(part, ".") && part != "." && part != ".." {
+			return true
+		}
+	}
+	return false
+}
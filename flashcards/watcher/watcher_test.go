@@ -0,0 +1,152 @@
+package watcher
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-ai-eng-flashcards/db"
+	"go-ai-eng-flashcards/importer"
+	"go-ai-eng-flashcards/models"
+	"go-ai-eng-flashcards/services"
+)
+
+// newTestWatcher returns a Watcher on a new temporary directory, syncing
+// into an in-memory store.
+func newTestWatcher(t *testing.T) (*Watcher, *services.NoteService) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store, err := db.OpenStore(context.Background(), db.DriverMemory, "", db.PoolConfig{}, logger)
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	notes := services.NewNoteService(store.Notes, store.UnitOfWork, services.ContentPolicy{MaxContentBytes: 1 << 20}, logger)
+	w, err := New(t.TempDir(), time.Millisecond, notes, logger)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return w, notes
+}
+
+func writeFile(t *testing.T, w *Watcher, name, content string) {
+	t.Helper()
+	path := filepath.Join(w.dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// syncedNote syncs the directory and returns the note for name, or nil.
+func syncedNote(t *testing.T, w *Watcher, notes *services.NoteService, name string) *models.Note {
+	t.Helper()
+	w.sync(context.Background())
+	stored, err := notes.NotesBySourcePrefix(context.Background(), SourcePrefix)
+	if err != nil {
+		t.Fatalf("NotesBySourcePrefix: %v", err)
+	}
+	return stored[SourcePrefix+name]
+}
+
+func TestSyncComparesContentNotTimes(t *testing.T) {
+	w, notes := newTestWatcher(t)
+	writeFile(t, w, "cells.md", "Cells are the unit of life.")
+
+	note := syncedNote(t, w, notes, "cells.md")
+	if note == nil || note.Content != "Cells are the unit of life." {
+		t.Fatalf("note after first sync = %+v", note)
+	}
+
+	// An edit through the API is kept while the file is unchanged, even if
+	// the file's modification time moves past the note's.
+	edited := "Edited in the app."
+	if _, err := notes.UpdateNote(context.Background(), int64(note.ID), &models.UpdateNoteRequest{Content: &edited}); err != nil {
+		t.Fatalf("UpdateNote: %v", err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(w.dir, "cells.md"), future, future); err != nil {
+		t.Fatal(err)
+	}
+	if note := syncedNote(t, w, notes, "cells.md"); note.Content != edited {
+		t.Fatalf("content after touching the file = %q, want the API edit kept", note.Content)
+	}
+
+	// A change to the contents updates the note, whatever the times say.
+	writeFile(t, w, "cells.md", "Cells divide by mitosis.")
+	past := time.Now().Add(-24 * time.Hour)
+	if err := os.Chtimes(filepath.Join(w.dir, "cells.md"), past, past); err != nil {
+		t.Fatal(err)
+	}
+	if note := syncedNote(t, w, notes, "cells.md"); note.Content != "Cells divide by mitosis." {
+		t.Fatalf("content after editing the file = %q", note.Content)
+	}
+}
+
+func TestSyncKeepsNoteOfOversizedFile(t *testing.T) {
+	w, notes := newTestWatcher(t)
+	writeFile(t, w, "big.md", "Small for now.")
+	if note := syncedNote(t, w, notes, "big.md"); note == nil {
+		t.Fatal("note was not created")
+	}
+
+	if err := os.Truncate(filepath.Join(w.dir, "big.md"), importer.MaxFileSize+1); err != nil {
+		t.Fatal(err)
+	}
+	note := syncedNote(t, w, notes, "big.md")
+	if note == nil || note.DeletedAt != nil || note.Content != "Small for now." {
+		t.Fatalf("note of oversized file = %+v, want it kept unchanged", note)
+	}
+	if _, ok := w.failed["big.md"]; !ok {
+		t.Fatalf("failed = %v, want big.md recorded", w.failed)
+	}
+}
+
+func TestSyncRecordsFailuresByFile(t *testing.T) {
+	w, notes := newTestWatcher(t)
+	// Valid Markdown, but a note needs content, so the import fails it.
+	writeFile(t, w, "topics/empty.md", "---\ntitle: Empty\n---\n")
+	writeFile(t, w, "broken.md", "---\ntitle: [unclosed\n---\nBody")
+
+	if note := syncedNote(t, w, notes, "topics/empty.md"); note != nil {
+		t.Fatalf("empty note was stored: %+v", note)
+	}
+	for _, name := range []string{"topics/empty.md", "broken.md"} {
+		if _, ok := w.failed[name]; !ok {
+			t.Fatalf("failed = %v, want %s recorded", w.failed, name)
+		}
+	}
+
+	// Fixing the file retries it.
+	writeFile(t, w, "topics/empty.md", "---\ntitle: Empty\n---\nNot any more.")
+	if note := syncedNote(t, w, notes, "topics/empty.md"); note == nil || note.Content != "Not any more." {
+		t.Fatalf("note after fixing the file = %+v", note)
+	}
+	if _, ok := w.failed["topics/empty.md"]; ok {
+		t.Fatalf("failed = %v, want topics/empty.md cleared", w.failed)
+	}
+}
+
+func TestSyncSoftDeletesRemovedFiles(t *testing.T) {
+	w, notes := newTestWatcher(t)
+	writeFile(t, w, "gone.md", "Soon gone.")
+	syncedNote(t, w, notes, "gone.md")
+
+	if err := os.Remove(filepath.Join(w.dir, "gone.md")); err != nil {
+		t.Fatal(err)
+	}
+	if note := syncedNote(t, w, notes, "gone.md"); note == nil || note.DeletedAt == nil {
+		t.Fatalf("note of removed file = %+v, want it soft-deleted", note)
+	}
+
+	writeFile(t, w, "gone.md", "Soon gone.")
+	if note := syncedNote(t, w, notes, "gone.md"); note == nil || note.DeletedAt != nil {
+		t.Fatalf("note of restored file = %+v, want it restored", note)
+	}
+}
@@ -0,0 +1,151 @@
+// Package web serves the built flashcards-app frontend from the binary.
+//
+// `make web` builds the Vite app and copies its dist/ output here, along with
+// gzip and brotli variants of the text assets; the files are embedded at
+// compile time. Without that step the embedded directory is empty and every
+// page request gets a 404 explaining how to build the frontend.
+package web
+
+import (
+	"bytes"
+	"embed"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+//go:embed all:dist
+var embedded embed.FS
+
+// encodings are the precompressed variants looked for next to each file, in
+// order of preference.
+var encodings = []struct {
+	name string
+	ext  string
+}{
+	{name: "br", ext: ".br"},
+	{name: "gzip", ext: ".gz"},
+}
+
+const notBuiltMessage = "The frontend is not included in this build. Run `make web` and rebuild to embed flashcards-app.\n"
+
+// Handler serves the embedded frontend. Existing files are served directly,
+// preferring a precompressed variant the client accepts. Any other GET for a
+// path without a file extension is answered with index.html so client-side
+// routes survive a reload.
+type Handler struct {
+	files   fs.FS
+	modTime time.Time
+}
+
+// NewHandler creates a handler over the embedded dist directory.
+func NewHandler() *Handler {
+	files, err := fs.Sub(embedded, "dist")
+	if err != nil {
+		// The embed pattern guarantees the directory exists.
+		panic(err)
+	}
+	// Embedded files carry no modification time; use the process start so
+	// If-Modified-Since still works for the lifetime of a deployment.
+	return &Handler{files: files, modTime: time.Now()}
+}
+
+// Built reports whether a frontend build was embedded.
+func (h *Handler) Built() bool {
+	_, err := fs.Stat(h.files, "index.html")
+	return err == nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = "index.html"
+	}
+
+	if !h.exists(name) {
+		// Missing assets are real 404s; anything that looks like a page is
+		// a client-side route.
+		if path.Ext(name) != "" {
+			http.NotFound(w, r)
+			return
+		}
+		name = "index.html"
+	}
+
+	if !h.exists(name) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(notBuiltMessage))
+		return
+	}
+
+	h.serveFile(w, r, name)
+}
+
+func (h *Handler) exists(name string) bool {
+	if strings.HasPrefix(path.Base(name), ".") {
+		return false
+	}
+	info, err := fs.Stat(h.files, name)
+	return err == nil && !info.IsDir()
+}
+
+func (h *Handler) serveFile(w http.ResponseWriter, r *http.Request, name string) {
+	header := w.Header()
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header.Set("Content-Type", contentType)
+	header.Set("Cache-Control", cacheControl(name))
+	header.Add("Vary", "Accept-Encoding")
+
+	file := name
+	accepted := r.Header.Get("Accept-Encoding")
+	for _, enc := range encodings {
+		if acceptsEncoding(accepted, enc.name) && h.exists(name+enc.ext) {
+			file = name + enc.ext
+			header.Set("Content-Encoding", enc.name)
+			break
+		}
+	}
+
+	data, err := fs.ReadFile(h.files, file)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	http.ServeContent(w, r, name, h.modTime, bytes.NewReader(data))
+}
+
+// cacheControl lets browsers keep Vite's content-hashed assets forever and
+// makes them revalidate everything else, so a deploy is picked up on the
+// next page load.
+func cacheControl(name string) string {
+	if strings.HasPrefix(name, "assets/") {
+		return "public, max-age=31536000, immutable"
+	}
+	return "no-cache"
+}
+
+func acceptsEncoding(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		token, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(token), encoding) {
+			continue
+		}
+		// An explicit q=0 means the encoding is refused.
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+	return false
+}